@@ -4,13 +4,11 @@ package duktape
 #cgo !windows CFLAGS: -std=c99 -O3 -Wall -Wno-unused-value -fomit-frame-pointer -fstrict-aliasing
 #cgo windows CFLAGS: -O3 -Wall -Wno-unused-value -fomit-frame-pointer -fstrict-aliasing
 
+#include <string.h>
 #include "duktape.h"
 #include "duk_logging.h"
 #include "duk_v1_compat.h"
 #include "duk_print_alert.h"
-static void _duk_eval_string(duk_context *ctx, const char *str) {
-  duk_eval_string(ctx, str);
-}
 static void _duk_compile(duk_context *ctx, duk_uint_t flags) {
   duk_compile(ctx, flags);
 }
@@ -23,12 +21,6 @@ static void _duk_compile_lstring(duk_context *ctx, duk_uint_t flags, const char
 static void _duk_compile_lstring_filename(duk_context *ctx, duk_uint_t flags, const char *src, duk_size_t len) {
 	duk_compile_lstring_filename(ctx, flags, src, len);
 }
-static void _duk_compile_string(duk_context *ctx, duk_uint_t flags, const char *src) {
-	duk_compile_string(ctx, flags, src);
-}
-static void _duk_compile_string_filename(duk_context *ctx, duk_uint_t flags, const char *src) {
-	duk_compile_string_filename(ctx, flags, src);
-}
 static void _duk_dump_context_stderr(duk_context *ctx) {
 	duk_dump_context_stderr(ctx);
 }
@@ -53,9 +45,6 @@ static void _duk_eval_lstring_noresult(duk_context *ctx, const char *src, duk_si
 static void _duk_eval_noresult(duk_context *ctx) {
 	duk_eval_noresult(ctx);
 }
-static void _duk_eval_string_noresult(duk_context *ctx, const char *src) {
-	duk_eval_string_noresult(ctx, src);
-}
 static duk_bool_t _duk_is_error(duk_context *ctx, duk_idx_t index) {
 	return duk_is_error(ctx, index);
 }
@@ -74,36 +63,55 @@ static duk_int_t _duk_pcompile_lstring(duk_context *ctx, duk_uint_t flags, const
 static duk_int_t _duk_pcompile_lstring_filename(duk_context *ctx, duk_uint_t flags, const char *src, duk_size_t len) {
 	return duk_pcompile_lstring_filename(ctx, flags, src, len);
 }
-static duk_int_t _duk_pcompile_string(duk_context *ctx, duk_uint_t flags, const char *src) {
-	return duk_pcompile_string(ctx, flags, src);
-}
-static duk_int_t _duk_pcompile_string_filename(duk_context *ctx, duk_uint_t flags, const char *src) {
-	return duk_pcompile_string_filename(ctx, flags, src);
-}
-static duk_int_t _duk_peval(duk_context *ctx) {
-	return duk_peval(ctx);
+static duk_ret_t _duk_random_call(duk_context *ctx, void *udata) {
+	duk_push_number(ctx, duk_random(ctx));
+	return 1;
+}
+// _duk_random calls duk_random in a safe call, the Go random source of the
+// heap throws its panics. The error is left on the stack when it throws.
+static duk_double_t _duk_random(duk_context *ctx, int *thrown) {
+	duk_double_t v;
+	if (duk_safe_call(ctx, _duk_random_call, NULL, 0, 1) != DUK_EXEC_SUCCESS) {
+		*thrown = 1;
+		return 0;
+	}
+	v = duk_get_number(ctx, -1);
+	duk_pop(ctx);
+	return v;
+}
+// _DUK_EXEC_COMPILE_ERROR is returned by _duk_peval_raw when the source
+// does not compile, to tell syntax errors of the source from the errors
+// thrown running it.
+#define _DUK_EXEC_COMPILE_ERROR 2
+
+// _duk_peval_raw is duk_eval_raw in safe mode.
+static duk_int_t _duk_peval_raw(duk_context *ctx, const char *src, duk_size_t len, duk_uint_t flags) {
+	duk_int_t rc = duk_compile_raw(ctx, src, len, flags | DUK_COMPILE_EVAL | DUK_COMPILE_SAFE);
+	if (rc != DUK_EXEC_SUCCESS) {
+		rc = _DUK_EXEC_COMPILE_ERROR;
+	} else {
+		duk_push_global_object(ctx);
+		rc = duk_pcall_method(ctx, 0);
+	}
+	if (flags & DUK_COMPILE_NORESULT) {
+		duk_pop(ctx);
+	}
+	return rc;
 }
-static duk_int_t _duk_peval_file(duk_context *ctx, const char *path) {
-	return duk_peval_file(ctx, path);
+static duk_int_t _duk_peval_file_raw(duk_context *ctx, const char *path) {
+	duk_push_string_file_raw(ctx, path, DUK_STRING_PUSH_SAFE);
+	duk_push_string(ctx, path);
+	return _duk_peval_raw(ctx, NULL, 0, 2);
 }
 static duk_int_t _duk_peval_file_noresult(duk_context *ctx, const char *path) {
 	return duk_peval_file_noresult(ctx, path);
 }
-static duk_int_t _duk_peval_lstring(duk_context *ctx, const char *src, duk_size_t len) {
-	return duk_peval_lstring(ctx, src, len);
-}
 static duk_int_t _duk_peval_lstring_noresult(duk_context *ctx, const char *src, duk_size_t len) {
 	return duk_peval_lstring_noresult(ctx, src, len);
 }
 static duk_int_t _duk_peval_noresult(duk_context *ctx) {
 	return duk_peval_noresult(ctx);
 }
-static duk_int_t _duk_peval_string(duk_context *ctx, const char *src) {
-	return duk_peval_string(ctx, src);
-}
-static duk_int_t _duk_peval_string_noresult(duk_context *ctx, const char *src) {
-	return duk_peval_string_noresult(ctx, src);
-}
 static const char *_duk_push_string_file(duk_context *ctx, const char *path) {
 	return duk_push_string_file(ctx, path);
 }
@@ -119,9 +127,6 @@ static void _duk_require_object_coercible(duk_context *ctx, duk_idx_t index) {
 static void _duk_require_type_mask(duk_context *ctx, duk_idx_t index, duk_uint_t mask) {
 	duk_require_type_mask(ctx, index, mask);
 }
-static const char *_duk_safe_to_string(duk_context *ctx, duk_idx_t index) {
-	return duk_safe_to_string(ctx, index);
-}
 static void _duk_xcopy_top(duk_context *to_ctx, duk_context *from_ctx, duk_idx_t count) {
 	duk_xcopy_top(to_ctx, from_ctx, count);
 }
@@ -209,6 +214,28 @@ func (d *Context) CallProp(objIndex int, nargs int) {
 	C.duk_call_prop(d.duk_context, C.duk_idx_t(objIndex), C.duk_idx_t(nargs))
 }
 
+// CborEncode encodes the value at index into CBOR, replacing it in place
+// with a plain buffer, and returns a copy of the encoded bytes.
+//
+// See: http://duktape.org/api.html#duk_cbor_encode
+func (d *Context) CborEncode(index int) []byte {
+	C.duk_cbor_encode(d.duk_context, C.duk_idx_t(index), 0)
+	rawPtr, outSize := d.GetBuffer(index)
+	return C.GoBytes(rawPtr, C.int(outSize))
+}
+
+// CborDecode decodes the CBOR data and pushes the resulting value to the
+// stack.
+//
+// See: http://duktape.org/api.html#duk_cbor_decode
+func (d *Context) CborDecode(data []byte) {
+	rawPtr := d.PushFixedBuffer(len(data))
+	if len(data) > 0 {
+		C.memcpy(rawPtr, unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+	C.duk_cbor_decode(d.duk_context, -1, 0)
+}
+
 // See: http://duktape.org/api.html#duk_check_stack
 func (d *Context) CheckStack(extra int) bool {
 	return int(C.duk_check_stack(d.duk_context, C.duk_idx_t(extra))) == 1
@@ -248,30 +275,30 @@ func (d *Context) CompileFile(flags uint, path string) {
 
 // See: http://duktape.org/api.html#duk_compile_lstring
 func (d *Context) CompileLstring(flags uint, src string, lenght int) {
-	__src__ := C.CString(src)
-	C._duk_compile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, C.duk_size_t(lenght))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_compile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_compile_lstring_filename
 func (d *Context) CompileLstringFilename(flags uint, src string, lenght int) {
-	__src__ := C.CString(src)
-	C._duk_compile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, C.duk_size_t(lenght))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_compile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_compile_string
 func (d *Context) CompileString(flags uint, src string) {
-	__src__ := C.CString(src)
-	C._duk_compile_string(d.duk_context, C.duk_uint_t(flags), __src__)
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_compile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_compile_string_filename
 func (d *Context) CompileStringFilename(flags uint, src string) {
-	__src__ := C.CString(src)
-	C._duk_compile_string_filename(d.duk_context, C.duk_uint_t(flags), __src__)
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_compile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_concat
@@ -296,6 +323,7 @@ func (d *Context) DelPropIndex(objIndex int, arrIndex uint) bool {
 
 // See: http://duktape.org/api.html#duk_del_prop_string
 func (d *Context) DelPropString(objIndex int, key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_del_prop_string(d.duk_context, C.duk_idx_t(objIndex), __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
@@ -312,6 +340,10 @@ func (d *Context) DestroyHeap() {
 	d.Gc(0)
 	C.duk_destroy_heap(d.duk_context)
 	d.duk_context = nil
+	if d.udata != nil {
+		heapData.delete(d.udata)
+		d.udata = nil
+	}
 }
 
 // See: http://duktape.org/api.html#duk_dump_context_stderr
@@ -396,16 +428,16 @@ func (d *Context) EvalFileNoresult(path string) {
 
 // See: http://duktape.org/api.html#duk_eval_lstring
 func (d *Context) EvalLstring(src string, lenght int) {
-	__src__ := C.CString(src)
-	C._duk_eval_lstring(d.duk_context, __src__, C.duk_size_t(lenght))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_eval_lstring(d.duk_context, __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_eval_lstring_noresult
 func (d *Context) EvalLstringNoresult(src string, lenght int) {
-	__src__ := C.CString(src)
-	C._duk_eval_lstring_noresult(d.duk_context, __src__, C.duk_size_t(lenght))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_eval_lstring_noresult(d.duk_context, __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_eval_noresult
@@ -415,16 +447,16 @@ func (d *Context) EvalNoresult() {
 
 // See: http://duktape.org/api.html#duk_eval_string
 func (d *Context) EvalString(src string) {
-	__src__ := C.CString(src)
-	C._duk_eval_string(d.duk_context, __src__)
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_eval_lstring(d.duk_context, __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_eval_string_noresult
 func (d *Context) EvalStringNoresult(src string) {
-	__src__ := C.CString(src)
-	C._duk_eval_string_noresult(d.duk_context, __src__)
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	C._duk_eval_lstring_noresult(d.duk_context, __src__, __len__)
 }
 
 // See: http://duktape.org/api.html#duk_fatal
@@ -434,6 +466,16 @@ func (d *Context) Fatal(errCode int, errMsg string) {
 	C.duk_fatal_raw(d.duk_context, __errMsg__)
 }
 
+// See: http://duktape.org/api.html#duk_free
+func (d *Context) Free(ptr unsafe.Pointer) {
+	C.duk_free(d.duk_context, ptr)
+}
+
+// See: http://duktape.org/api.html#duk_free_raw
+func (d *Context) FreeRaw(ptr unsafe.Pointer) {
+	C.duk_free_raw(d.duk_context, ptr)
+}
+
 // See: http://duktape.org/api.html#duk_gc
 func (d *Context) Gc(flags uint) {
 	C.duk_gc(d.duk_context, C.duk_uint_t(flags))
@@ -473,6 +515,7 @@ func (d *Context) GetFinalizer(index int) {
 
 // See: http://duktape.org/api.html#duk_get_global_string
 func (d *Context) GetGlobalString(key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_get_global_string(d.duk_context, __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
@@ -494,14 +537,32 @@ func (d *Context) GetLength(index int) int {
 	return int(C.duk_get_length(d.duk_context, C.duk_idx_t(index)))
 }
 
+// GetLstring returns "" for symbols, see GetSymbol.
+//
 // See: http://duktape.org/api.html#duk_get_lstring
 func (d *Context) GetLstring(index int) string {
-	if s := C.duk_get_lstring(d.duk_context, C.duk_idx_t(index), nil); s != nil {
-		return C.GoString(s)
+	if d.IsSymbol(index) {
+		return ""
+	}
+	var length C.duk_size_t
+	if s := C.duk_get_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
 
+// See: http://duktape.org/api.html#duk_get_memory_functions
+func (d *Context) GetMemoryFunctions() MemoryFunctions {
+	var funcs C.duk_memory_functions
+	C.duk_get_memory_functions(d.duk_context, &funcs)
+	return MemoryFunctions{
+		Alloc:   unsafe.Pointer(funcs.alloc_func),
+		Realloc: unsafe.Pointer(funcs.realloc_func),
+		Free:    unsafe.Pointer(funcs.free_func),
+		Udata:   funcs.udata,
+	}
+}
+
 // See: http://duktape.org/api.html#duk_get_magic
 func (d *Context) GetMagic(index int) int {
 	return int(C.duk_get_magic(d.duk_context, C.duk_idx_t(index)))
@@ -522,6 +583,11 @@ func (d *Context) GetProp(objIndex int) bool {
 	return int(C.duk_get_prop(d.duk_context, C.duk_idx_t(objIndex))) == 1
 }
 
+// See: http://duktape.org/api.html#duk_get_prop_desc
+func (d *Context) GetPropDesc(objIndex int, flags uint) {
+	C.duk_get_prop_desc(d.duk_context, C.duk_idx_t(objIndex), C.duk_uint_t(flags))
+}
+
 // See: http://duktape.org/api.html#duk_get_prop_index
 func (d *Context) GetPropIndex(objIndex int, arrIndex uint) bool {
 	return int(C.duk_get_prop_index(d.duk_context, C.duk_idx_t(objIndex), C.duk_uarridx_t(arrIndex))) == 1
@@ -529,6 +595,7 @@ func (d *Context) GetPropIndex(objIndex int, arrIndex uint) bool {
 
 // See: http://duktape.org/api.html#duk_get_prop_string
 func (d *Context) GetPropString(objIndex int, key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_get_prop_string(d.duk_context, C.duk_idx_t(objIndex), __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
@@ -540,10 +607,16 @@ func (d *Context) GetPrototype(index int) {
 	C.duk_get_prototype(d.duk_context, C.duk_idx_t(index))
 }
 
+// GetString returns "" for symbols, see GetSymbol.
+//
 // See: http://duktape.org/api.html#duk_get_string
 func (d *Context) GetString(i int) string {
-	if s := C.duk_get_string(d.duk_context, C.duk_idx_t(i)); s != nil {
-		return C.GoString(s)
+	if d.IsSymbol(i) {
+		return ""
+	}
+	var length C.duk_size_t
+	if s := C.duk_get_lstring(d.duk_context, C.duk_idx_t(i), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -558,9 +631,15 @@ func (d *Context) GetTopIndex() int {
 	return int(C.duk_get_top_index(d.duk_context))
 }
 
+// GetType reports TypeSymbol for symbols, which Duktape types as strings.
+//
 // See: http://duktape.org/api.html#duk_get_type
 func (d *Context) GetType(index int) Type {
-	return Type(C.duk_get_type(d.duk_context, C.duk_idx_t(index)))
+	t := Type(C.duk_get_type(d.duk_context, C.duk_idx_t(index)))
+	if t == TypeString && d.IsSymbol(index) {
+		return TypeSymbol
+	}
+	return t
 }
 
 // See: http://duktape.org/api.html#duk_get_type_mask
@@ -585,6 +664,7 @@ func (d *Context) HasPropIndex(objIndex int, arrIndex uint) bool {
 
 // See: http://duktape.org/api.html#duk_has_prop_string
 func (d *Context) HasPropString(objIndex int, key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_has_prop_string(d.duk_context, C.duk_idx_t(objIndex), __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
@@ -773,23 +853,30 @@ func (d *Context) NormalizeIndex(index int) int {
 
 // See: http://duktape.org/api.html#duk_pcall
 func (d *Context) Pcall(nargs int) int {
-	return int(C.duk_pcall(d.duk_context, C.duk_idx_t(nargs)))
+	result := int(C.duk_pcall(d.duk_context, C.duk_idx_t(nargs)))
+	d.repanic()
+	return result
 }
 
 // See: http://duktape.org/api.html#duk_pcall_method
 func (d *Context) PcallMethod(nargs int) int {
-	return int(C.duk_pcall_method(d.duk_context, C.duk_idx_t(nargs)))
+	result := int(C.duk_pcall_method(d.duk_context, C.duk_idx_t(nargs)))
+	d.repanic()
+	return result
 }
 
 // See: http://duktape.org/api.html#duk_pcall_prop
 func (d *Context) PcallProp(objIndex int, nargs int) int {
-	return int(C.duk_pcall_prop(d.duk_context, C.duk_idx_t(objIndex), C.duk_idx_t(nargs)))
+	result := int(C.duk_pcall_prop(d.duk_context, C.duk_idx_t(objIndex), C.duk_idx_t(nargs)))
+	d.repanic()
+	return result
 }
 
 // See: http://duktape.org/api.html#duk_pcompile
 func (d *Context) Pcompile(flags uint) error {
+	source := d.rawString(-2)
 	result := int(C._duk_pcompile(d.duk_context, C.duk_uint_t(flags)))
-	return d.castStringToError(result)
+	return d.compileError(result, source)
 }
 
 // See: http://duktape.org/api.html#duk_pcompile_file
@@ -797,52 +884,62 @@ func (d *Context) PcompileFile(flags uint, path string) error {
 	__path__ := C.CString(path)
 	result := int(C._duk_pcompile_file(d.duk_context, C.duk_uint_t(flags), __path__))
 	C.free(unsafe.Pointer(__path__))
-	return d.castStringToError(result)
+	if result != 0 {
+		return d.compileError(result, readSource(path))
+	}
+	return nil
 }
 
 // See: http://duktape.org/api.html#duk_pcompile_lstring
 func (d *Context) PcompileLstring(flags uint, src string, lenght int) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_pcompile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, C.duk_size_t(lenght)))
-	C.free(unsafe.Pointer(__src__))
-	return d.castStringToError(result)
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_pcompile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, __len__))
+	return d.compileError(result, src[:__len__])
 }
 
 // See: http://duktape.org/api.html#duk_pcompile_lstring_filename
 func (d *Context) PcompileLstringFilename(flags uint, src string, lenght int) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_pcompile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, C.duk_size_t(lenght)))
-	C.free(unsafe.Pointer(__src__))
-	return d.castStringToError(result)
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_pcompile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, __len__))
+	return d.compileError(result, src[:__len__])
 }
 
 // See: http://duktape.org/api.html#duk_pcompile_string
 func (d *Context) PcompileString(flags uint, src string) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_pcompile_string(d.duk_context, C.duk_uint_t(flags), __src__))
-	C.free(unsafe.Pointer(__src__))
-	return d.castStringToError(result)
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_pcompile_lstring(d.duk_context, C.duk_uint_t(flags), __src__, __len__))
+	return d.compileError(result, src[:__len__])
 }
 
 // See: http://duktape.org/api.html#duk_pcompile_string_filename
 func (d *Context) PcompileStringFilename(flags uint, src string) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_pcompile_string_filename(d.duk_context, C.duk_uint_t(flags), __src__))
-	C.free(unsafe.Pointer(__src__))
-	return d.castStringToError(result)
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_pcompile_lstring_filename(d.duk_context, C.duk_uint_t(flags), __src__, __len__))
+	return d.compileError(result, src[:__len__])
 }
 
 // See: http://duktape.org/api.html#duk_peval
 func (d *Context) Peval() error {
-	result := int(C._duk_peval(d.duk_context))
+	source := d.rawString(-1)
+	result := int(C._duk_peval_raw(d.duk_context, nil, 0, 1|C.DUK_COMPILE_NOFILENAME))
+	if result == C._DUK_EXEC_COMPILE_ERROR {
+		return d.compileError(result, source)
+	}
 	return d.castStringToError(result)
 }
 
 // See: http://duktape.org/api.html#duk_peval_file
 func (d *Context) PevalFile(path string) error {
 	__path__ := C.CString(path)
-	result := int(C._duk_peval_file(d.duk_context, __path__))
+	result := int(C._duk_peval_file_raw(d.duk_context, __path__))
 	C.free(unsafe.Pointer(__path__))
+	if result == C._DUK_EXEC_COMPILE_ERROR {
+		return d.compileError(result, readSource(path))
+	}
 	return d.castStringToError(result)
 }
 
@@ -851,54 +948,65 @@ func (d *Context) PevalFileNoresult(path string) int {
 	__path__ := C.CString(path)
 	result := int(C._duk_peval_file_noresult(d.duk_context, __path__))
 	C.free(unsafe.Pointer(__path__))
+	d.repanic()
 	return result
 }
 
 // See: http://duktape.org/api.html#duk_peval_lstring
 func (d *Context) PevalLstring(src string, lenght int) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_peval_lstring(d.duk_context, __src__, C.duk_size_t(lenght)))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_peval_raw(d.duk_context, __src__, __len__, C.DUK_COMPILE_NOSOURCE|C.DUK_COMPILE_NOFILENAME))
+	if result == C._DUK_EXEC_COMPILE_ERROR {
+		return d.compileError(result, src[:__len__])
+	}
 	return d.castStringToError(result)
-
 }
 
 // See: http://duktape.org/api.html#duk_peval_lstring_noresult
 func (d *Context) PevalLstringNoresult(src string, lenght int) int {
-	__src__ := C.CString(src)
-	result := int(C._duk_peval_lstring_noresult(d.duk_context, __src__, C.duk_size_t(lenght)))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapLstring(src, lenght)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_peval_lstring_noresult(d.duk_context, __src__, __len__))
+	d.repanic()
 	return result
 }
 
 // See: http://duktape.org/api.html#duk_peval_noresult
 func (d *Context) PevalNoresult() int {
-	return int(C._duk_peval_noresult(d.duk_context))
+	result := int(C._duk_peval_noresult(d.duk_context))
+	d.repanic()
+	return result
 }
 
 // See: http://duktape.org/api.html#duk_peval_string
 func (d *Context) PevalString(src string) error {
-	__src__ := C.CString(src)
-	result := int(C._duk_peval_string(d.duk_context, __src__))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_peval_raw(d.duk_context, __src__, __len__, C.DUK_COMPILE_NOSOURCE|C.DUK_COMPILE_NOFILENAME))
+	if result == C._DUK_EXEC_COMPILE_ERROR {
+		return d.compileError(result, src[:__len__])
+	}
 	return d.castStringToError(result)
 }
 
 // See: http://duktape.org/api.html#duk_peval_string_noresult
 func (d *Context) PevalStringNoresult(src string) int {
-	__src__ := C.CString(src)
-	result := int(C._duk_peval_string_noresult(d.duk_context, __src__))
-	C.free(unsafe.Pointer(__src__))
+	src = d.heapString(src)
+	__src__, __len__ := lstring(src, len(src))
+	result := int(C._duk_peval_lstring_noresult(d.duk_context, __src__, __len__))
+	d.repanic()
 	return result
 }
 
 func (d *Context) castStringToError(result int) error {
+	d.repanic()
 	if result == 0 {
 		return nil
 	}
 
 	err := &Error{}
-	for _, key := range []string{"name", "message", "fileName", "lineNumber", "stack"} {
+	for _, key := range []string{"name", "message", "fileName", "lineNumber", "stack", goStackProp} {
 		d.GetPropString(-1, key)
 
 		switch key {
@@ -914,10 +1022,18 @@ func (d *Context) castStringToError(result int) error {
 			}
 		case "stack":
 			err.Stack = d.SafeToString(-1)
+		case goStackProp:
+			if d.IsString(-1) {
+				err.GoStack = d.GetString(-1)
+			}
 		}
 
 		d.Pop()
 	}
+	err.Code = ErrorCode(d.GetErrorCode(-1))
+	err.Frames = d.errorFrames(-1, err.Stack)
+	d.applySourceMaps(err)
+	err.err = d.goError(-1)
 
 	return err
 }
@@ -1040,13 +1156,14 @@ func (d *Context) PushInt(val int) {
 
 // See: http://duktape.org/api.html#duk_push_lstring
 func (d *Context) PushLstring(str string, lenght int) string {
-	__str__ := C.CString(str)
-	var result string
-	if s := C.duk_push_lstring(d.duk_context, __str__, C.duk_size_t(lenght)); s != nil {
-		result = C.GoString(s)
+	if lenght < 0 {
+		lenght = 0
 	}
-	C.free(unsafe.Pointer(__str__))
-	return result
+	if lenght < len(str) {
+		str = str[:lenght]
+	}
+	d.pushString(str)
+	return str
 }
 
 // See: http://duktape.org/api.html#duk_push_nan
@@ -1071,13 +1188,8 @@ func (d *Context) PushObject() int {
 
 // See: http://duktape.org/api.html#duk_push_string
 func (d *Context) PushString(str string) string {
-	__str__ := C.CString(str)
-	var result string
-	if s := C.duk_push_string(d.duk_context, __str__); s != nil {
-		result = C.GoString(s)
-	}
-	C.free(unsafe.Pointer(__str__))
-	return result
+	d.pushString(str)
+	return str
 }
 
 // See: http://duktape.org/api.html#duk_push_string_file
@@ -1128,6 +1240,7 @@ func (d *Context) PushUndefined() {
 
 // See: http://duktape.org/api.html#duk_put_global_string
 func (d *Context) PutGlobalString(key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_put_global_string(d.duk_context, __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
@@ -1146,12 +1259,23 @@ func (d *Context) PutPropIndex(objIndex int, arrIndex uint) bool {
 
 // See: http://duktape.org/api.html#duk_put_prop_string
 func (d *Context) PutPropString(objIndex int, key string) bool {
+	key = d.heapString(key)
 	__key__ := C.CString(key)
 	result := int(C.duk_put_prop_string(d.duk_context, C.duk_idx_t(objIndex), __key__)) == 1
 	C.free(unsafe.Pointer(__key__))
 	return result
 }
 
+// See: http://duktape.org/api.html#duk_realloc
+func (d *Context) Realloc(ptr unsafe.Pointer, size int) unsafe.Pointer {
+	return C.duk_realloc(d.duk_context, ptr, C.duk_size_t(size))
+}
+
+// See: http://duktape.org/api.html#duk_realloc_raw
+func (d *Context) ReallocRaw(ptr unsafe.Pointer, size int) unsafe.Pointer {
+	return C.duk_realloc_raw(d.duk_context, ptr, C.duk_size_t(size))
+}
+
 // See: http://duktape.org/api.html#duk_remove
 func (d *Context) Remove(index int) {
 	C.duk_remove(d.duk_context, C.duk_idx_t(index))
@@ -1202,8 +1326,9 @@ func (d *Context) RequireInt(index int) int {
 
 // See: http://duktape.org/api.html#duk_require_lstring
 func (d *Context) RequireLstring(index int) string {
-	if s := C.duk_require_lstring(d.duk_context, C.duk_idx_t(index), nil); s != nil {
-		return C.GoString(s)
+	var length C.duk_size_t
+	if s := C.duk_require_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -1245,8 +1370,9 @@ func (d *Context) RequireStackTop(top int) {
 
 // See: http://duktape.org/api.html#duk_require_string
 func (d *Context) RequireString(index int) string {
-	if s := C.duk_require_string(d.duk_context, C.duk_idx_t(index)); s != nil {
-		return C.GoString(s)
+	var length C.duk_size_t
+	if s := C.duk_require_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -1283,27 +1409,49 @@ func (d *Context) ResizeBuffer(index int, newSize int) unsafe.Pointer {
 
 // See: http://duktape.org/api.html#duk_safe_call
 func (d *Context) SafeCall(fn, args *[0]byte, nargs, nrets int) int {
-	return int(C.duk_safe_call(
+	result := int(C.duk_safe_call(
 		d.duk_context,
 		fn,
 		unsafe.Pointer(&args),
 		C.duk_idx_t(nargs),
 		C.duk_idx_t(nrets),
 	))
+	d.repanic()
+	return result
 }
 
+// SafeToLstring converts symbols like String(symbol) does, e.g. to
+// "Symbol(foo)", rather than failing as a string coercion.
+//
 // See: http://duktape.org/api.html#duk_safe_to_lstring
 func (d *Context) SafeToLstring(index int) string {
-	if s := C.duk_safe_to_lstring(d.duk_context, C.duk_idx_t(index), nil); s != nil {
-		return C.GoString(s)
+	if s, ok := d.GetSymbol(index); ok {
+		index = d.NormalizeIndex(index)
+		d.pushString(s.String())
+		d.Replace(index)
+		return s.String()
+	}
+	var length C.duk_size_t
+	if s := C.duk_safe_to_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
 
+// SafeToString converts symbols like String(symbol) does, e.g. to
+// "Symbol(foo)", rather than failing as a string coercion.
+//
 // See: http://duktape.org/api.html#duk_safe_to_string
 func (d *Context) SafeToString(index int) string {
-	if s := C._duk_safe_to_string(d.duk_context, C.duk_idx_t(index)); s != nil {
-		return C.GoString(s)
+	if s, ok := d.GetSymbol(index); ok {
+		index = d.NormalizeIndex(index)
+		d.pushString(s.String())
+		d.Replace(index)
+		return s.String()
+	}
+	var length C.duk_size_t
+	if s := C.duk_safe_to_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -1397,8 +1545,9 @@ func (d *Context) ToInt32(index int) int32 {
 
 // See: http://duktape.org/api.html#duk_to_lstring
 func (d *Context) ToLstring(index int) string {
-	if s := C.duk_to_lstring(d.duk_context, C.duk_idx_t(index), nil); s != nil {
-		return C.GoString(s)
+	var length C.duk_size_t
+	if s := C.duk_to_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -1430,8 +1579,9 @@ func (d *Context) ToPrimitive(index int, hint int) {
 
 // See: http://duktape.org/api.html#duk_to_string
 func (d *Context) ToString(index int) string {
-	if s := C.duk_to_string(d.duk_context, C.duk_idx_t(index)); s != nil {
-		return C.GoString(s)
+	var length C.duk_size_t
+	if s := C.duk_to_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return d.goString(s, length)
 	}
 	return ""
 }
@@ -1566,23 +1716,245 @@ func (d *Context) ConfigBuffer(bufferIdx int, buffer []byte) {
 	)
 }
 
+// See: http://duktape.org/api.html#duk_push_bare_object
+func (d *Context) PushBareObject() int {
+	return int(C.duk_push_bare_object(d.duk_context))
+}
+
+// See: http://duktape.org/api.html#duk_push_bare_array
+func (d *Context) PushBareArray() int {
+	return int(C.duk_push_bare_array(d.duk_context))
+}
+
+// PushProxyObject pushes a Proxy for the target object at -2 and the handler
+// object at -1, popping both. See PushProxy for traps implemented in Go.
+//
+// See: http://duktape.org/api.html#duk_push_proxy
+func (d *Context) PushProxyObject(flags uint) int {
+	return int(C.duk_push_proxy(d.duk_context, C.duk_uint_t(flags)))
+}
+
+// See: http://duktape.org/api.html#duk_push_new_target
+func (d *Context) PushNewTarget() {
+	C.duk_push_new_target(d.duk_context)
+}
+
+// See: http://duktape.org/api.html#duk_seal
+func (d *Context) Seal(objIndex int) {
+	C.duk_seal(d.duk_context, C.duk_idx_t(objIndex))
+}
+
+// See: http://duktape.org/api.html#duk_freeze
+func (d *Context) Freeze(objIndex int) {
+	C.duk_freeze(d.duk_context, C.duk_idx_t(objIndex))
+}
+
+// See: http://duktape.org/api.html#duk_is_constructable
+func (d *Context) IsConstructable(index int) bool {
+	return int(C.duk_is_constructable(d.duk_context, C.duk_idx_t(index))) == 1
+}
+
+// See: http://duktape.org/api.html#duk_require_constructable
+func (d *Context) RequireConstructable(index int) {
+	C.duk_require_constructable(d.duk_context, C.duk_idx_t(index))
+}
+
+// See: http://duktape.org/api.html#duk_require_constructor_call
+func (d *Context) RequireConstructorCall() {
+	C.duk_require_constructor_call(d.duk_context)
+}
+
+// See: http://duktape.org/api.html#duk_require_object
+func (d *Context) RequireObject(index int) {
+	C.duk_require_object(d.duk_context, C.duk_idx_t(index))
+}
+
+// See: http://duktape.org/api.html#duk_samevalue
+func (d *Context) Samevalue(index1, index2 int) bool {
+	return int(C.duk_samevalue(d.duk_context, C.duk_idx_t(index1), C.duk_idx_t(index2))) == 1
+}
+
+// See: http://duktape.org/api.html#duk_set_length
+func (d *Context) SetLength(index int, length int) {
+	C.duk_set_length(d.duk_context, C.duk_idx_t(index), C.duk_size_t(length))
+}
+
+// See: http://duktape.org/api.html#duk_pull
+func (d *Context) Pull(fromIndex int) {
+	C.duk_pull(d.duk_context, C.duk_idx_t(fromIndex))
+}
+
+// See: http://duktape.org/api.html#duk_random
+//
+// A panic of the Flags.Random source of the heap is raised again, as an
+// *Error without Flags.PropagatePanics.
+func (d *Context) Random() float64 {
+	var thrown C.int
+	v := float64(C._duk_random(d.duk_context, &thrown))
+	if thrown != 0 {
+		defer d.Pop()
+		panic(d.castStringToError(1))
+	}
+	return v
+}
+
+// See: http://duktape.org/api.html#duk_get_now
+func (d *Context) GetNow() float64 {
+	return float64(C.duk_get_now(d.duk_context))
+}
+
+// See: http://duktape.org/api.html#duk_opt_boolean
+func (d *Context) OptBoolean(index int, defValue bool) bool {
+	var def C.duk_bool_t
+	if defValue {
+		def = 1
+	}
+	return int(C.duk_opt_boolean(d.duk_context, C.duk_idx_t(index), def)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_opt_buffer
+func (d *Context) OptBuffer(index int, defPtr unsafe.Pointer, defSize uint) (rawPtr unsafe.Pointer, outSize uint) {
+	rawPtr = C.duk_opt_buffer(d.duk_context, C.duk_idx_t(index), (*C.duk_size_t)(unsafe.Pointer(&outSize)), defPtr, C.duk_size_t(defSize))
+	return rawPtr, outSize
+}
+
+// See: http://duktape.org/api.html#duk_opt_buffer_data
+func (d *Context) OptBufferData(index int, defPtr unsafe.Pointer, defSize uint) (rawPtr unsafe.Pointer, outSize uint) {
+	rawPtr = C.duk_opt_buffer_data(d.duk_context, C.duk_idx_t(index), (*C.duk_size_t)(unsafe.Pointer(&outSize)), defPtr, C.duk_size_t(defSize))
+	return rawPtr, outSize
+}
+
+// See: http://duktape.org/api.html#duk_opt_context
+func (d *Context) OptContext(index int, defValue *Context) *Context {
+	if defValue == nil {
+		return contextFromPointer(C.duk_opt_context(d.duk_context, C.duk_idx_t(index), nil))
+	}
+	ctx := C.duk_opt_context(d.duk_context, C.duk_idx_t(index), defValue.duk_context)
+	if ctx == defValue.duk_context {
+		return defValue
+	}
+	return contextFromPointer(ctx)
+}
+
+// See: http://duktape.org/api.html#duk_opt_heapptr
+func (d *Context) OptHeapptr(index int, defValue unsafe.Pointer) unsafe.Pointer {
+	return C.duk_opt_heapptr(d.duk_context, C.duk_idx_t(index), defValue)
+}
+
+// See: http://duktape.org/api.html#duk_opt_int
+func (d *Context) OptInt(index int, defValue int) int {
+	return int(C.duk_opt_int(d.duk_context, C.duk_idx_t(index), C.duk_int_t(defValue)))
+}
+
+// See: http://duktape.org/api.html#duk_opt_lstring
+func (d *Context) OptLstring(index int, defValue string) string {
+	var length C.duk_size_t
+	__def__, __len__ := lstring(defValue, len(defValue))
+	s := C.duk_opt_lstring(d.duk_context, C.duk_idx_t(index), &length, __def__, __len__)
+	return d.goString(s, length)
+}
+
+// See: http://duktape.org/api.html#duk_opt_number
+func (d *Context) OptNumber(index int, defValue float64) float64 {
+	return float64(C.duk_opt_number(d.duk_context, C.duk_idx_t(index), C.duk_double_t(defValue)))
+}
+
+// See: http://duktape.org/api.html#duk_opt_pointer
+func (d *Context) OptPointer(index int, defValue unsafe.Pointer) unsafe.Pointer {
+	return C.duk_opt_pointer(d.duk_context, C.duk_idx_t(index), defValue)
+}
+
+// See: http://duktape.org/api.html#duk_opt_string
+func (d *Context) OptString(index int, defValue string) string {
+	return d.OptLstring(index, defValue)
+}
+
+// See: http://duktape.org/api.html#duk_opt_uint
+func (d *Context) OptUint(index int, defValue uint) uint {
+	return uint(C.duk_opt_uint(d.duk_context, C.duk_idx_t(index), C.duk_uint_t(defValue)))
+}
+
+// The *Lstring property functions take keys of any content, NUL bytes
+// included, and stand in for the duk_*_literal macros as well.
+
+// See: http://duktape.org/api.html#duk_get_prop_lstring
+func (d *Context) GetPropLstring(objIndex int, key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_get_prop_lstring(d.duk_context, C.duk_idx_t(objIndex), __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_put_prop_lstring
+func (d *Context) PutPropLstring(objIndex int, key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_put_prop_lstring(d.duk_context, C.duk_idx_t(objIndex), __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_has_prop_lstring
+func (d *Context) HasPropLstring(objIndex int, key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_has_prop_lstring(d.duk_context, C.duk_idx_t(objIndex), __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_del_prop_lstring
+func (d *Context) DelPropLstring(objIndex int, key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_del_prop_lstring(d.duk_context, C.duk_idx_t(objIndex), __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_get_global_lstring
+func (d *Context) GetGlobalLstring(key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_get_global_lstring(d.duk_context, __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_put_global_lstring
+func (d *Context) PutGlobalLstring(key string) bool {
+	key = d.heapString(key)
+	__key__, __len__ := lstring(key, len(key))
+	return int(C.duk_put_global_lstring(d.duk_context, __key__, __len__)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_get_prop_heapptr
+func (d *Context) GetPropHeapptr(objIndex int, ptr unsafe.Pointer) bool {
+	return int(C.duk_get_prop_heapptr(d.duk_context, C.duk_idx_t(objIndex), ptr)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_put_prop_heapptr
+func (d *Context) PutPropHeapptr(objIndex int, ptr unsafe.Pointer) bool {
+	return int(C.duk_put_prop_heapptr(d.duk_context, C.duk_idx_t(objIndex), ptr)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_has_prop_heapptr
+func (d *Context) HasPropHeapptr(objIndex int, ptr unsafe.Pointer) bool {
+	return int(C.duk_has_prop_heapptr(d.duk_context, C.duk_idx_t(objIndex), ptr)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_del_prop_heapptr
+func (d *Context) DelPropHeapptr(objIndex int, ptr unsafe.Pointer) bool {
+	return int(C.duk_del_prop_heapptr(d.duk_context, C.duk_idx_t(objIndex), ptr)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_get_global_heapptr
+func (d *Context) GetGlobalHeapptr(ptr unsafe.Pointer) bool {
+	return int(C.duk_get_global_heapptr(d.duk_context, ptr)) == 1
+}
+
+// See: http://duktape.org/api.html#duk_put_global_heapptr
+func (d *Context) PutGlobalHeapptr(ptr unsafe.Pointer) bool {
+	return int(C.duk_put_global_heapptr(d.duk_context, ptr)) == 1
+}
+
 /**
  * Unimplemented.
  *
- * CharCodeAt see: http://duktape.org/api.html#duk_char_code_at
  * CreateHeap see: http://duktape.org/api.html#duk_create_heap
- * DecodeString see: http://duktape.org/api.html#duk_decode_string
- * Free see: http://duktape.org/api.html#duk_free
- * FreeRaw see: http://duktape.org/api.html#duk_free_raw
  * GetCFunction see: http://duktape.org/api.html#duk_get_c_function
- * GetMemoryFunctions see: http://duktape.org/api.html#duk_get_memory_functions
- * MapString see: http://duktape.org/api.html#duk_map_string
- * PushSprintf see: http://duktape.org/api.html#duk_push_sprintf
  * PushVsprintf see: http://duktape.org/api.html#duk_push_vsprintf
- * PutFunctionList see: http://duktape.org/api.html#duk_put_function_list
- * PutNumberList see: http://duktape.org/api.html#duk_put_number_list
- * Realloc see: http://duktape.org/api.html#duk_realloc
- * ReallocRaw see: http://duktape.org/api.html#duk_realloc_raw
  * RequireCFunction see: http://duktape.org/api.html#duk_require_c_function
  * GetBufferData see: http://duktape.org/api.html#duk_get_buffer_data
  * StealBuffer see: http://duktape.org/api.html#duk_steal_buffer
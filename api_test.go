@@ -1,6 +1,13 @@
 package duktape
 
-import . "gopkg.in/check.v1"
+import (
+	"math"
+	"testing"
+	"time"
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
 
 func (s *DuktapeSuite) TestPevalString(c *C) {
 	s.ctx.EvalString(`"Golang love Duktape!"`)
@@ -10,7 +17,7 @@ func (s *DuktapeSuite) TestPevalString(c *C) {
 
 func (s *DuktapeSuite) TestPevalString_Error(c *C) {
 	err := s.ctx.PevalString("var = 'foo';")
-	c.Assert(err.(*Error).Type, Equals, "SyntaxError")
+	c.Assert(err.(*CompileError).Err.Type, Equals, "SyntaxError")
 }
 
 func (s *DuktapeSuite) TestPevalFile_Error(c *C) {
@@ -40,3 +47,224 @@ func (s *DuktapeSuite) assertErrorInCtx(c *C, code int, msg string) {
 	c.Assert(s.ctx.GetErrorCode(-1), Equals, code)
 	c.Assert(s.ctx.SafeToString(-1), Equals, msg)
 }
+
+func (s *DuktapeSuite) TestPushStringEmbeddedNul(c *C) {
+	str := s.ctx.PushString("foo\x00bar")
+	c.Assert(str, Equals, "foo\x00bar")
+	c.Assert(s.ctx.GetLength(-1), Equals, 7)
+	c.Assert(s.ctx.GetString(-1), Equals, "foo\x00bar")
+
+	str = s.ctx.PushLstring("a\x00bc", 3)
+	c.Assert(str, Equals, "a\x00b")
+	c.Assert(s.ctx.GetLength(-1), Equals, 3)
+
+	str = s.ctx.PushLstring("abc", 10)
+	c.Assert(str, Equals, "abc")
+	c.Assert(s.ctx.GetString(-1), Equals, "abc")
+
+	s.ctx.PushString("")
+	c.Assert(s.ctx.IsString(-1), Equals, true)
+	c.Assert(s.ctx.GetLength(-1), Equals, 0)
+}
+
+func (s *DuktapeSuite) TestEvalStringEmbeddedNul(c *C) {
+	err := s.ctx.PevalString("'a\x00b'.length")
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 3)
+	s.ctx.Pop()
+
+	s.ctx.EvalString("")
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalLstring("1 + 2; garbage", 5)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 3)
+	s.ctx.Pop()
+
+	s.ctx.PushString("source.js")
+	err = s.ctx.PcompileStringFilename(0, "'x\x00y'")
+	c.Assert(err, IsNil)
+	s.ctx.Call(0)
+	c.Assert(s.ctx.GetString(-1), Equals, "x\x00y")
+}
+
+func (s *DuktapeSuite) TestPushBareObjectAndArray(c *C) {
+	s.ctx.PushBareObject()
+	s.ctx.GetPropString(-1, "toString")
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+	s.ctx.Pop2()
+
+	arr := s.ctx.PushBareArray()
+	c.Assert(s.ctx.IsArray(arr), Equals, true)
+	s.ctx.GetPropString(arr, "push")
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+	s.ctx.Pop()
+
+	s.ctx.PushInt(1)
+	s.ctx.PutPropIndex(arr, 0)
+	s.ctx.PushInt(2)
+	s.ctx.PutPropIndex(arr, 1)
+	s.ctx.SetLength(arr, 1)
+	c.Assert(s.ctx.GetLength(arr), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestSealFreeze(c *C) {
+	s.ctx.PevalString(`({a: 1})`)
+	s.ctx.Seal(-1)
+	s.ctx.PutGlobalString("sealed")
+	s.ctx.PevalString(`({a: 1})`)
+	s.ctx.Freeze(-1)
+	s.ctx.PutGlobalString("frozen")
+
+	err := s.ctx.PevalString(`
+		sealed.a = 2; sealed.b = 3; frozen.a = 2;
+		[Object.isSealed(sealed), sealed.a, sealed.b, Object.isFrozen(frozen), frozen.a].join()
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,2,,true,1")
+}
+
+func (s *DuktapeSuite) TestOptGetters(c *C) {
+	s.ctx.PushUndefined()
+	s.ctx.PushInt(7)
+	s.ctx.PushString("str")
+	s.ctx.PushBoolean(false)
+
+	c.Assert(s.ctx.OptInt(0, 42), Equals, 42)
+	c.Assert(s.ctx.OptInt(1, 42), Equals, 7)
+	c.Assert(s.ctx.OptInt(10, 42), Equals, 42)
+	c.Assert(s.ctx.OptUint(0, 42), Equals, uint(42))
+	c.Assert(s.ctx.OptNumber(1, 0.5), Equals, 7.0)
+	c.Assert(s.ctx.OptNumber(0, 0.5), Equals, 0.5)
+	c.Assert(s.ctx.OptString(2, "def"), Equals, "str")
+	c.Assert(s.ctx.OptString(0, "def"), Equals, "def")
+	c.Assert(s.ctx.OptLstring(0, "d\x00f"), Equals, "d\x00f")
+	c.Assert(s.ctx.OptBoolean(3, true), Equals, false)
+	c.Assert(s.ctx.OptBoolean(0, true), Equals, true)
+	c.Assert(s.ctx.OptPointer(0, nil), Equals, unsafe.Pointer(nil))
+	c.Assert(s.ctx.OptHeapptr(0, nil), Equals, unsafe.Pointer(nil))
+	c.Assert(s.ctx.OptContext(0, s.ctx), Equals, s.ctx)
+
+	_, size := s.ctx.OptBuffer(0, nil, 0)
+	c.Assert(size, Equals, uint(0))
+	c.Assert(s.ctx.GetTop(), Equals, 4)
+
+	s.ctx.PushGoFunction(func(ctx *Context) int {
+		ctx.PushInt(ctx.OptInt(0, 5))
+		return 1
+	})
+	s.ctx.PutGlobalString("opt")
+	err := s.ctx.PevalString(`opt() + opt(1)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 6)
+}
+
+func (s *DuktapeSuite) TestPushNewTarget(c *C) {
+	s.ctx.PushGoFunction(func(ctx *Context) int {
+		ctx.PushNewTarget()
+		ctx.PushBoolean(ctx.IsUndefined(-1))
+		return 1
+	})
+	s.ctx.PutGlobalString("target")
+
+	err := s.ctx.PevalString(`[target(), typeof new target()].join()`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,object")
+	s.ctx.Pop()
+
+	s.ctx.GetGlobalString("target")
+	c.Assert(s.ctx.IsConstructable(-1), Equals, true)
+	s.ctx.PushBareObject()
+	c.Assert(s.ctx.IsConstructable(-1), Equals, false)
+	c.Assert(s.ctx.Samevalue(-1, -1), Equals, true)
+	c.Assert(s.ctx.Samevalue(-1, -2), Equals, false)
+	s.ctx.Pull(-2)
+	c.Assert(s.ctx.IsFunction(-1), Equals, true)
+}
+
+func (s *DuktapeSuite) TestLstringAndHeapptrProps(c *C) {
+	s.ctx.PushObject()
+	s.ctx.PushInt(1)
+	c.Assert(s.ctx.PutPropLstring(-2, "a\x00b"), Equals, true)
+	c.Assert(s.ctx.HasPropLstring(-1, "a\x00b"), Equals, true)
+	c.Assert(s.ctx.HasPropString(-1, "a"), Equals, false)
+	c.Assert(s.ctx.GetPropLstring(-1, "a\x00b"), Equals, true)
+	c.Assert(s.ctx.GetInt(-1), Equals, 1)
+	s.ctx.Pop()
+
+	s.ctx.PushString("key")
+	key := s.ctx.GetHeapptr(-1)
+	s.ctx.PushInt(2)
+	c.Assert(s.ctx.PutPropHeapptr(-3, key), Equals, true)
+	c.Assert(s.ctx.HasPropHeapptr(-2, key), Equals, true)
+	c.Assert(s.ctx.GetPropHeapptr(-2, key), Equals, true)
+	c.Assert(s.ctx.GetInt(-1), Equals, 2)
+	s.ctx.Pop()
+	c.Assert(s.ctx.DelPropHeapptr(-2, key), Equals, true)
+	c.Assert(s.ctx.DelPropLstring(-2, "a\x00b"), Equals, true)
+	c.Assert(s.ctx.HasPropLstring(-2, "a\x00b"), Equals, false)
+	s.ctx.Remove(-2)
+
+	s.ctx.PushInt(3)
+	c.Assert(s.ctx.PutGlobalHeapptr(key), Equals, true)
+	c.Assert(s.ctx.GetGlobalHeapptr(key), Equals, true)
+	c.Assert(s.ctx.GetInt(-1), Equals, 3)
+	s.ctx.PushInt(4)
+	c.Assert(s.ctx.PutGlobalLstring("other"), Equals, true)
+	c.Assert(s.ctx.GetGlobalLstring("other"), Equals, true)
+	c.Assert(s.ctx.GetInt(-1), Equals, 4)
+}
+
+func (s *DuktapeSuite) TestRandomAndNow(c *C) {
+	r := s.ctx.Random()
+	c.Assert(r >= 0 && r < 1, Equals, true)
+
+	now := s.ctx.GetNow()
+	c.Assert(math.Abs(now-float64(time.Now().UnixNano()/1e6)) < 1000, Equals, true)
+}
+
+func (s *DuktapeSuite) TestPushProxyObject(c *C) {
+	s.ctx.PushObject()
+	s.ctx.PevalString(`({get: function(t, k) { return 'proxied ' + k; }})`)
+	s.ctx.PushProxyObject(0)
+	s.ctx.GetPropString(-1, "foo")
+	c.Assert(s.ctx.GetString(-1), Equals, "proxied foo")
+	c.Assert(s.ctx.GetTop(), Equals, 2)
+}
+
+func BenchmarkPushString(b *testing.B) {
+	ctx := New()
+	defer ctx.DestroyHeap()
+	str := "Golang love Duktape!"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx.PushString(str)
+		ctx.Pop()
+	}
+}
+
+func BenchmarkPushLstring(b *testing.B) {
+	ctx := New()
+	defer ctx.DestroyHeap()
+	str := "Golang love Duktape!"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx.PushLstring(str, 6)
+		ctx.Pop()
+	}
+}
+
+func BenchmarkPevalString(b *testing.B) {
+	ctx := New()
+	defer ctx.DestroyHeap()
+	src := "1 + 2"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx.PevalString(src)
+		ctx.Pop()
+	}
+}
@@ -0,0 +1,68 @@
+package duktape
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestCborEncode(c *C) {
+	s.ctx.PevalString(`({name: "duk", list: [1, 2.5, "x"], ok: true, nothing: null})`)
+	data := s.ctx.CborEncode(-1)
+	c.Assert(s.ctx.IsBuffer(-1), Equals, true)
+
+	var v map[string]interface{}
+	err := cbor.Unmarshal(data, &v)
+	c.Assert(err, IsNil)
+	c.Assert(v["name"], Equals, "duk")
+	c.Assert(v["ok"], Equals, true)
+	c.Assert(v["nothing"], IsNil)
+	list := v["list"].([]interface{})
+	c.Assert(list, HasLen, 3)
+	c.Assert(list[0], Equals, uint64(1))
+	c.Assert(list[1], Equals, 2.5)
+	c.Assert(list[2], Equals, "x")
+}
+
+func (s *DuktapeSuite) TestCborDecode(c *C) {
+	data, err := cbor.Marshal(map[string]interface{}{
+		"id":   42,
+		"tags": []string{"a", "b"},
+		"raw":  []byte{1, 2, 3},
+	})
+	c.Assert(err, IsNil)
+
+	s.ctx.CborDecode(data)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+	s.ctx.GetPropString(-1, "id")
+	c.Assert(s.ctx.GetInt(-1), Equals, 42)
+	s.ctx.Pop()
+	s.ctx.GetPropString(-1, "tags")
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `["a","b"]`)
+	s.ctx.Pop()
+	s.ctx.GetPropString(-1, "raw")
+	_, size := s.ctx.GetBuffer(-1)
+	c.Assert(size, Equals, uint(3))
+}
+
+func (s *DuktapeSuite) TestCborRoundTrip(c *C) {
+	s.ctx.PevalString(`({a: [1, {b: "c"}], d: -7})`)
+	data := s.ctx.CborEncode(-1)
+
+	other := New()
+	defer other.DestroyHeap()
+	other.CborDecode(data)
+	c.Assert(other.JsonEncode(-1), Equals, `{"a":[1,{"b":"c"}],"d":-7}`)
+
+	type message struct {
+		Name  string   `cbor:"name"`
+		Count int      `cbor:"count"`
+		Tags  []string `cbor:"tags"`
+	}
+	in := message{Name: "duk", Count: 3, Tags: []string{"x", "y"}}
+	data, err := cbor.Marshal(in)
+	c.Assert(err, IsNil)
+	s.ctx.CborDecode(data)
+	var out message
+	c.Assert(cbor.Unmarshal(s.ctx.CborEncode(-1), &out), IsNil)
+	c.Assert(out, DeepEquals, in)
+}
@@ -0,0 +1,97 @@
+package duktape
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Duktape keeps strings as CESU-8: characters outside the Basic
+// Multilingual Plane, as created by scripts, are stored as two 3 byte
+// encoded UTF-16 surrogates, which Go does not accept as UTF-8. A 4 byte
+// UTF-8 sequence coming from Go is kept as a single character instead, so
+// it neither compares equal to the same character written in a script nor
+// has the length 2 scripts expect.
+//
+// CESU8ToUTF8 and UTF8ToCESU8 convert between the two representations, and
+// Flags.NormalizeUTF8 applies them to all strings crossing the boundary.
+// Both conversions replace what can not be represented with U+FFFD: every
+// byte which is not part of a valid sequence and every unpaired surrogate.
+
+const replacementChar = "�"
+
+// CESU8ToUTF8 converts the Duktape string s to valid UTF-8, joining
+// surrogate pairs into single characters.
+func CESU8ToUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		if hi, ok := decodeSurrogate(s[i:]); ok {
+			lo, ok := decodeSurrogate(s[i+3:])
+			if ok && hi < 0xDC00 && lo >= 0xDC00 {
+				buf = appendRune(buf, utf16.DecodeRune(hi, lo))
+				i += 6
+			} else {
+				buf = append(buf, replacementChar...)
+				i += 3
+			}
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		buf = appendRune(buf, r)
+		i += size
+	}
+	return string(buf)
+}
+
+// UTF8ToCESU8 converts the UTF-8 string s to the representation Duktape
+// uses for strings created by scripts, splitting characters outside the
+// Basic Multilingual Plane into surrogate pairs.
+func UTF8ToCESU8(s string) string {
+	if utf8.ValidString(s) && !hasNonBMP(s) {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s)+len(s)/2)
+	for _, r := range s {
+		if r >= 0x10000 {
+			hi, lo := utf16.EncodeRune(r)
+			buf = appendSurrogate(buf, hi)
+			buf = appendSurrogate(buf, lo)
+			continue
+		}
+		buf = appendRune(buf, r)
+	}
+	return string(buf)
+}
+
+// hasNonBMP reports whether the valid UTF-8 string s has a 4 byte sequence.
+func hasNonBMP(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0xF0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSurrogate decodes the 3 byte encoded surrogate s starts with.
+func decodeSurrogate(s string) (rune, bool) {
+	if len(s) < 3 || s[0] != 0xED || s[1] < 0xA0 || s[1] > 0xBF || s[2] < 0x80 || s[2] > 0xBF {
+		return 0, false
+	}
+	return 0xD000 | rune(s[1]&0x3F)<<6 | rune(s[2]&0x3F), true
+}
+
+func appendSurrogate(buf []byte, r rune) []byte {
+	return append(buf, byte(0xE0|r>>12), byte(0x80|(r>>6)&0x3F), byte(0x80|r&0x3F))
+}
+
+func appendRune(buf []byte, r rune) []byte {
+	var tmp [utf8.UTFMax]byte
+	n := utf8.EncodeRune(tmp[:], r)
+	return append(buf, tmp[:n]...)
+}
@@ -0,0 +1,129 @@
+package duktape
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestCESU8ToUTF8(c *C) {
+	c.Assert(CESU8ToUTF8("plain ascii"), Equals, "plain ascii")
+	c.Assert(CESU8ToUTF8("añ€"), Equals, "añ€")
+	c.Assert(CESU8ToUTF8("x\xed\xa0\xbd\xed\xb8\x80y"), Equals, "x😀y")
+
+	// unpaired and reversed surrogates
+	c.Assert(CESU8ToUTF8("\xed\xa0\xbdx"), Equals, "�x")
+	c.Assert(CESU8ToUTF8("x\xed\xb8\x80"), Equals, "x�")
+	c.Assert(CESU8ToUTF8("\xed\xb8\x80\xed\xa0\xbd"), Equals, "��")
+
+	// invalid bytes
+	c.Assert(CESU8ToUTF8("a\xffb\xc3"), Equals, "a�b�")
+}
+
+func (s *DuktapeSuite) TestUTF8ToCESU8(c *C) {
+	c.Assert(UTF8ToCESU8("plain ascii"), Equals, "plain ascii")
+	c.Assert(UTF8ToCESU8("añ€"), Equals, "añ€")
+	c.Assert(UTF8ToCESU8("x😀y"), Equals, "x\xed\xa0\xbd\xed\xb8\x80y")
+	c.Assert(UTF8ToCESU8("a\xffb\xf0\x9f"), Equals, "a�b��")
+}
+
+func (s *DuktapeSuite) TestCESU8RoundTripFullRange(c *C) {
+	var b strings.Builder
+	for r := rune(0); r <= utf8.MaxRune; r++ {
+		if r >= 0xD800 && r <= 0xDFFF {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	all := b.String()
+
+	cesu := UTF8ToCESU8(all)
+	c.Assert(utf8.ValidString(cesu), Equals, false)
+	c.Assert(CESU8ToUTF8(cesu), Equals, all)
+}
+
+func (s *DuktapeSuite) TestNormalizeUTF8(c *C) {
+	ctx := NewWithFlags(&Flags{NormalizeUTF8: true})
+	defer ctx.DestroyHeap()
+
+	ctx.PushString("x😀")
+	ctx.PutGlobalString("fromGo")
+	err := ctx.PevalString(`fromGo.length + ':' + (fromGo === 'x😀') + ':' + fromGo.charCodeAt(2).toString(16)`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "3:true:de00")
+	ctx.Pop()
+
+	err = ctx.PevalString(`'x😀 \ud83d'`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "x😀 �")
+	c.Assert(ctx.SafeToString(-1), Equals, "x😀 �")
+	ctx.Pop()
+
+	ctx.PushString("bad\xffbyte")
+	c.Assert(ctx.GetString(-1), Equals, "bad�byte")
+	ctx.Pop()
+
+	sym := ctx.PushHiddenSymbol("secret")
+	got, ok := ctx.GetSymbol(-1)
+	c.Assert(ok, Equals, true)
+	c.Assert(got, Equals, sym)
+	c.Assert(ctx.GetString(-1), Equals, "")
+}
+
+func (s *DuktapeSuite) TestNormalizeUTF8FullRange(c *C) {
+	ctx := NewWithFlags(&Flags{NormalizeUTF8: true})
+	defer ctx.DestroyHeap()
+
+	// every 7th codepoint keeps the test fast while still hitting every
+	// plane and all surrogate combinations
+	err := ctx.PevalString(`
+		var parts = [];
+		for (var cp = 0; cp <= 0x10ffff; cp += 7) {
+			if (cp >= 0xd800 && cp <= 0xdfff) continue;
+			if (cp < 0x10000) {
+				parts.push(String.fromCharCode(cp));
+			} else {
+				var v = cp - 0x10000;
+				parts.push(String.fromCharCode(0xd800 + (v >> 10), 0xdc00 + (v & 0x3ff)));
+			}
+		}
+		parts.join('');
+	`)
+	c.Assert(err, IsNil)
+	var b strings.Builder
+	for r := rune(0); r <= utf8.MaxRune; r += 7 {
+		if r < 0xD800 || r > 0xDFFF {
+			b.WriteRune(r)
+		}
+	}
+	fromJS := ctx.GetString(-1)
+	c.Assert(fromJS == b.String(), Equals, true)
+
+	ctx.PushString(fromJS)
+	c.Assert(ctx.Equals(-1, -2), Equals, true)
+}
+
+func (s *DuktapeSuite) TestNormalizeUTF8Keys(c *C) {
+	ctx := NewWithFlags(&Flags{NormalizeUTF8: true})
+	defer ctx.DestroyHeap()
+
+	err := ctx.PevalString(`var o = {}; o['😀'] = 'js'; o`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.HasPropString(-1, "😀"), Equals, true)
+	ctx.GetPropString(-1, "😀")
+	c.Assert(ctx.GetString(-1), Equals, "js")
+	ctx.Pop()
+
+	ctx.PushString("go")
+	ctx.PutPropLstring(-2, "x😀")
+	ctx.PutGlobalString("o😀")
+	err = ctx.PevalString(`this['o😀']['x😀'] + ':' + Object.keys(o).join()`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "go:😀,x😀")
+	ctx.Pop()
+
+	c.Assert(ctx.GetGlobalString("o😀"), Equals, true)
+	c.Assert(ctx.DelPropString(-1, "😀"), Equals, true)
+	c.Assert(ctx.HasPropString(-1, "😀"), Equals, false)
+}
@@ -0,0 +1,173 @@
+package duktape
+
+/*
+#include "duktape.h"
+*/
+import "C"
+import (
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// compileOffsetProp is set by the compiler, see duk__add_compiler_error_line
+// in duktape.c, to the byte offset in the source of the token it failed at.
+const compileOffsetProp = "\xff" + "CompileOffset"
+
+// reCompileLine matches the line Duktape appends to the messages of the
+// syntax errors of the compiler.
+var reCompileLine = regexp.MustCompile(`\(line (\d+)(?:, end of input)?\)$`)
+
+// CompileError is returned by the Pcompile* and Peval* functions when the
+// source does not compile. The errors thrown running a compiled source are
+// returned as *Error.
+//
+// The location is the one in the compiled source, which Source is a line
+// of, even when a source map is registered for Filename; the source map
+// only applies to Err.
+type CompileError struct {
+	// Err is the SyntaxError thrown by the compiler.
+	Err *Error
+	// Filename is the file name the source was compiled with.
+	Filename string
+	// Line is the line of the error in the source, starting at 1.
+	Line int
+	// Column is the column of the error in the line, starting at 1 and
+	// counted in characters, or 0 when it is not known.
+	Column int
+	// Source is the line of the source with the error, if the source is
+	// known.
+	Source string
+	// Caret marks the position of the error under Source: a '^' at the
+	// column, or '~' under the whole line when the column is not known.
+	Caret string
+}
+
+func (e *CompileError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the *Error of the SyntaxError.
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// compileError returns the error at the top of the stack left by a failed
+// compilation of source. Syntax errors are returned as *CompileError.
+func (d *Context) compileError(result int, source string) error {
+	if result == 0 {
+		return d.castStringToError(result)
+	}
+
+	// the location in source, read before castStringToError applies the
+	// source maps to it
+	offset, filename, line := -1, "", 0
+	if d.IsObject(-1) {
+		offset = d.getIntProp(-1, compileOffsetProp)
+		filename = d.getStringProp(-1, "fileName")
+		if line = d.getIntProp(-1, "lineNumber"); line < 0 {
+			line = 0
+		}
+	}
+
+	err := d.castStringToError(result)
+	e, ok := err.(*Error)
+	if !ok || !e.IsSyntaxError() {
+		return err
+	}
+	return newCompileError(e, filename, line, source, offset)
+}
+
+// newCompileError locates the syntax error e, raised compiling source with
+// the given file name, from the byte offset of the failing token when known
+// and from line and the message otherwise.
+func newCompileError(e *Error, filename string, line int, source string, offset int) *CompileError {
+	ce := &CompileError{Err: e, Filename: filename, Line: line}
+	if m := reCompileLine.FindStringSubmatch(e.Message); m != nil {
+		ce.Line, _ = strconv.Atoi(m[1])
+	}
+	if source == "" {
+		return ce
+	}
+
+	var start, end int
+	if offset >= 0 && offset <= len(source) {
+		start = strings.LastIndexByte(source[:offset], '\n') + 1
+		ce.Line = strings.Count(source[:start], "\n") + 1
+	} else {
+		start = lineStart(source, ce.Line)
+		if start < 0 {
+			return ce
+		}
+	}
+	if end = strings.IndexByte(source[start:], '\n'); end < 0 {
+		end = len(source)
+	} else {
+		end += start
+	}
+	ce.Source = CESU8ToUTF8(strings.TrimSuffix(source[start:end], "\r"))
+
+	if offset >= 0 && offset <= len(source) {
+		prefix := CESU8ToUTF8(source[start:offset])
+		ce.Column = utf8.RuneCountInString(prefix) + 1
+		ce.Caret = indent(prefix) + "^"
+	} else {
+		trimmed := strings.TrimLeft(ce.Source, " \t")
+		indentation := ce.Source[:len(ce.Source)-len(trimmed)]
+		ce.Caret = indentation + strings.Repeat("~", utf8.RuneCountInString(trimmed))
+	}
+	return ce
+}
+
+// lineStart returns the byte offset of the line, starting at 1, in source,
+// or -1 if source has fewer lines.
+func lineStart(source string, line int) int {
+	if line < 1 {
+		return -1
+	}
+	start := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(source[start:], '\n')
+		if i < 0 {
+			return -1
+		}
+		start += i + 1
+	}
+	return start
+}
+
+// indent returns the blanks lining up with prefix, keeping its tabs so the
+// marker stays in place whatever the tab width.
+func indent(prefix string) string {
+	b := make([]byte, 0, len(prefix))
+	for _, r := range prefix {
+		if r == '\t' {
+			b = append(b, '\t')
+		} else {
+			b = append(b, ' ')
+		}
+	}
+	return string(b)
+}
+
+// rawString returns the bytes of the string at index as they are, without
+// the conversion of Flags.NormalizeUTF8, or "" if it is not a string.
+func (d *Context) rawString(index int) string {
+	var length C.duk_size_t
+	if s := C.duk_get_lstring(d.duk_context, C.duk_idx_t(index), &length); s != nil {
+		return C.GoStringN(s, C.int(length))
+	}
+	return ""
+}
+
+// readSource returns the content of the file at path, or "" if it can not
+// be read.
+func readSource(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
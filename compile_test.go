@@ -0,0 +1,99 @@
+package duktape
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestCompileError(c *C) {
+	err := s.ctx.PevalString("x = 1;\n\ty = 2 @ 3;")
+	ce, ok := err.(*CompileError)
+	c.Assert(ok, Equals, true)
+	c.Assert(ce.Filename, Equals, "eval")
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Column, Equals, 8)
+	c.Assert(ce.Source, Equals, "\ty = 2 @ 3;")
+	c.Assert(ce.Caret, Equals, "\t      ^")
+	c.Assert(err, ErrorMatches, "SyntaxError: .*")
+	c.Assert(errors.Unwrap(err).(*Error).Type, Equals, "SyntaxError")
+	s.ctx.Pop()
+
+	s.ctx.PushString("main.js")
+	err = s.ctx.PcompileStringFilename(0, "var s = 'ä' +;")
+	ce = err.(*CompileError)
+	c.Assert(ce.Filename, Equals, "main.js")
+	c.Assert(ce.Line, Equals, 1)
+	c.Assert(ce.Column, Equals, 14)
+	c.Assert(ce.Caret, Equals, "             ^")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString("function f() {\n  return 1 +\n")
+	ce = err.(*CompileError)
+	c.Assert(ce.Line, Equals, 3)
+	c.Assert(ce.Column, Equals, 1)
+	c.Assert(ce.Source, Equals, "")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`JSON.parse('{')`)
+	c.Assert(err, FitsTypeOf, &Error{})
+	c.Assert(err.(*Error).IsSyntaxError(), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`eval('var = 1')`)
+	c.Assert(err, FitsTypeOf, &Error{})
+	s.ctx.Pop()
+}
+
+func (s *DuktapeSuite) TestCompileErrorFile(c *C) {
+	dir, err := ioutil.TempDir("", "duktape")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "broken.js")
+	c.Assert(ioutil.WriteFile(path, []byte("var a = 1;\nvar b = ;\n"), 0644), IsNil)
+
+	err = s.ctx.PevalFile(path)
+	ce, ok := err.(*CompileError)
+	c.Assert(ok, Equals, true)
+	c.Assert(ce.Filename, Equals, path)
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Column, Equals, 9)
+	c.Assert(ce.Source, Equals, "var b = ;")
+	c.Assert(ce.Caret, Equals, "        ^")
+	s.ctx.Pop()
+
+	err = s.ctx.PcompileFile(0, path)
+	c.Assert(err.(*CompileError).Line, Equals, 2)
+	s.ctx.Pop()
+}
+
+func (s *DuktapeSuite) TestNewCompileErrorWithoutOffset(c *C) {
+	e := &Error{Type: "SyntaxError", Message: "unexpected end of input (line 2, end of input)"}
+	ce := newCompileError(e, "input", 0, "a = 1;\n  b = (c\n", -1)
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Column, Equals, 0)
+	c.Assert(ce.Source, Equals, "  b = (c")
+	c.Assert(ce.Caret, Equals, "  ~~~~~~")
+
+	ce = newCompileError(e, "input", 0, "", -1)
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Source, Equals, "")
+}
+
+func (s *DuktapeSuite) TestCompileErrorSourceMap(c *C) {
+	c.Assert(s.ctx.RegisterSourceMap("bundle.min.js", []byte(bundleMap)), IsNil)
+
+	s.ctx.PushString("bundle.min.js")
+	err := s.ctx.PcompileStringFilename(0, "function a(){}\nvar b = ;\n")
+	ce := err.(*CompileError)
+	c.Assert(ce.Filename, Equals, "bundle.min.js")
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Column, Equals, 9)
+	c.Assert(ce.Source, Equals, "var b = ;")
+	c.Assert(ce.Err.FileName, Equals, "src/b.js")
+	c.Assert(ce.Err.LineNumber, Equals, 3)
+}
@@ -32,6 +32,9 @@ const (
 	TypeBuffer    Type = C.DUK_TYPE_BUFFER
 	TypePointer   Type = C.DUK_TYPE_POINTER
 	TypeLightFunc Type = C.DUK_TYPE_LIGHTFUNC
+	// TypeSymbol is not a Duktape type: Duktape stores symbols as strings,
+	// GetType tells them apart.
+	TypeSymbol Type = 0x100
 )
 
 const (
@@ -59,19 +62,66 @@ const (
 )
 
 const (
-	ErrUnimplemented int = 50 + iota
+	DefPropWritable          uint = C.DUK_DEFPROP_WRITABLE
+	DefPropEnumerable        uint = C.DUK_DEFPROP_ENUMERABLE
+	DefPropConfigurable      uint = C.DUK_DEFPROP_CONFIGURABLE
+	DefPropHaveWritable      uint = C.DUK_DEFPROP_HAVE_WRITABLE
+	DefPropHaveEnumerable    uint = C.DUK_DEFPROP_HAVE_ENUMERABLE
+	DefPropHaveConfigurable  uint = C.DUK_DEFPROP_HAVE_CONFIGURABLE
+	DefPropHaveValue         uint = C.DUK_DEFPROP_HAVE_VALUE
+	DefPropHaveGetter        uint = C.DUK_DEFPROP_HAVE_GETTER
+	DefPropHaveSetter        uint = C.DUK_DEFPROP_HAVE_SETTER
+	DefPropForce             uint = C.DUK_DEFPROP_FORCE
+	DefPropSetWritable       uint = C.DUK_DEFPROP_SET_WRITABLE
+	DefPropClearWritable     uint = C.DUK_DEFPROP_CLEAR_WRITABLE
+	DefPropSetEnumerable     uint = C.DUK_DEFPROP_SET_ENUMERABLE
+	DefPropClearEnumerable   uint = C.DUK_DEFPROP_CLEAR_ENUMERABLE
+	DefPropSetConfigurable   uint = C.DUK_DEFPROP_SET_CONFIGURABLE
+	DefPropClearConfigurable uint = C.DUK_DEFPROP_CLEAR_CONFIGURABLE
+)
+
+// ErrorCode is the code of an error class of the language, e.g. in
+// Error.Code.
+type ErrorCode int
+
+// The error codes are untyped constants, so they are both the int codes of
+// GetErrorCode, Error, PushErrorObject and the like, and ErrorCode values.
+const (
+	ErrUnimplemented = 50 + iota
 	ErrUnsupported
 
-	ErrNone      int = C.DUK_ERR_NONE
-	ErrError     int = C.DUK_ERR_ERROR
-	ErrEval      int = C.DUK_ERR_EVAL_ERROR
-	ErrRange     int = C.DUK_ERR_RANGE_ERROR
-	ErrReference int = C.DUK_ERR_REFERENCE_ERROR
-	ErrSyntax    int = C.DUK_ERR_SYNTAX_ERROR
-	ErrType      int = C.DUK_ERR_TYPE_ERROR
-	ErrURI       int = C.DUK_ERR_URI_ERROR
+	ErrNone      = C.DUK_ERR_NONE
+	ErrError     = C.DUK_ERR_ERROR
+	ErrEval      = C.DUK_ERR_EVAL_ERROR
+	ErrRange     = C.DUK_ERR_RANGE_ERROR
+	ErrReference = C.DUK_ERR_REFERENCE_ERROR
+	ErrSyntax    = C.DUK_ERR_SYNTAX_ERROR
+	ErrType      = C.DUK_ERR_TYPE_ERROR
+	ErrURI       = C.DUK_ERR_URI_ERROR
 )
 
+// String returns the name of the error class, e.g. TypeError, or "" for
+// ErrNone and unknown codes.
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrError:
+		return "Error"
+	case ErrEval:
+		return "EvalError"
+	case ErrRange:
+		return "RangeError"
+	case ErrReference:
+		return "ReferenceError"
+	case ErrSyntax:
+		return "SyntaxError"
+	case ErrType:
+		return "TypeError"
+	case ErrURI:
+		return "URIError"
+	}
+	return ""
+}
+
 const (
 	// Returned error values
 	ErrRetUnimplemented int = -(ErrUnimplemented + iota)
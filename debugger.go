@@ -219,57 +219,88 @@ func (d *Debugger) Notify(ctx *Context, notifyFunc DebugNotifyFunc) int {
 	return (int)(C.duk_debugger_notify(ctx.duk_context, (C.duk_idx_t)(nvalues)))
 }
 
+// The debugger callbacks are called by Duktape: a panic of the Go functions
+// behind them must not unwind through its C frames. A panic in a transport
+// function is reported as a stream error, which detaches the debugger, and a
+// panic in the request function as an application error reply.
+//
+// ctx is the calling Duktape thread, a C pointer with no *Context behind it
+// to transmute to, so the callbacks get a Context wrapping just that thread.
+
 //export goDebugReadFunction
-func goDebugReadFunction(dData unsafe.Pointer, buffer *C.char, length C.duk_size_t) C.duk_size_t {
+func goDebugReadFunction(dData unsafe.Pointer, buffer *C.char, length C.duk_size_t) (n C.duk_size_t) {
+	defer func() {
+		if recover() != nil {
+			n = 0
+		}
+	}()
 	a := ptrToAttachment(dData)
 	b := ptrToSlice(buffer, length)
 	return (C.duk_size_t)(a.readFunc(a.uData, b))
 }
 
 //export goDebugWriteFunction
-func goDebugWriteFunction(dData unsafe.Pointer, buffer *C.char, length C.duk_size_t) C.duk_size_t {
+func goDebugWriteFunction(dData unsafe.Pointer, buffer *C.char, length C.duk_size_t) (n C.duk_size_t) {
+	defer func() {
+		if recover() != nil {
+			n = 0
+		}
+	}()
 	a := ptrToAttachment(dData)
 	b := ptrToSlice(buffer, length)
 	return (C.duk_size_t)(a.writeFunc(a.uData, b))
 }
 
 //export goDebugPeekFunction
-func goDebugPeekFunction(dData unsafe.Pointer) C.duk_size_t {
+func goDebugPeekFunction(dData unsafe.Pointer) (n C.duk_size_t) {
+	defer func() {
+		if recover() != nil {
+			n = 0
+		}
+	}()
 	a := ptrToAttachment(dData)
 	return (C.duk_size_t)(a.peekFunc(a.uData))
 }
 
 //export goDebugReadFlushFunction
 func goDebugReadFlushFunction(dData unsafe.Pointer) {
+	defer func() { recover() }()
 	a := ptrToAttachment(dData)
 	a.readFlushFunc(a.uData)
 }
 
 //export goDebugWriteFlushFunction
 func goDebugWriteFlushFunction(dData unsafe.Pointer) {
+	defer func() { recover() }()
 	a := ptrToAttachment(dData)
 	a.writeFlushFunc(a.uData)
 }
 
 //export goDebugRequestFunction
-func goDebugRequestFunction(ctx *C.duk_context, dData unsafe.Pointer, nvalues C.duk_idx_t) C.duk_idx_t {
-	a := ptrToAttachment(dData)
+func goDebugRequestFunction(ctx *C.duk_context, dData unsafe.Pointer, nvalues C.duk_idx_t) (nrets C.duk_idx_t) {
 	d := contextFromPointer(ctx)
-	d.transmute(unsafe.Pointer(ctx))
+	defer func() {
+		if r := recover(); r != nil {
+			// a negative result replies with the error message on top
+			d.PushString(fmt.Sprint("panic: ", r))
+			nrets = -1
+		}
+	}()
+	a := ptrToAttachment(dData)
 	return (C.duk_idx_t)(a.requestFunc(d, a.uData, int(nvalues)))
 }
 
 //export goDebugDetachedFunction
 func goDebugDetachedFunction(ctx *C.duk_context, dData unsafe.Pointer) {
+	defer C.free(dData)
+	defer func() { recover() }()
 	s := ptrToSlot(dData)
 	debugger = DukDebugger()
 	a, err := debugger.removeAttachment(s)
 	if err != nil {
-		panic(err)
+		return
 	}
-	defer C.free(dData)
 	d := contextFromPointer(ctx)
-	d.transmute(unsafe.Pointer(ctx))
 	if a.detachedFunc != nil {
 		a.detachedFunc(d, a.uData)
 	}
@@ -283,7 +314,7 @@ func ptrToSlice(buffer *C.char, length C.duk_size_t) []byte {
 }
 
 func ptrToSlot(dData unsafe.Pointer) int {
-	return int(*(*int32)(dData))
+	return int(*(*C.uint8_t)(dData))
 }
 
 func slotToPtr(slot int) unsafe.Pointer {
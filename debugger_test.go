@@ -0,0 +1,51 @@
+package duktape
+
+import (
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
+
+// The heap is built without DUK_USE_DEBUGGER_SUPPORT, so the callbacks are
+// called here the way Duktape calls them.
+
+func (s *DuktapeSuite) TestDebugRequestFunction(c *C) {
+	request := func(ctx *Context, uData unsafe.Pointer, nValues int) int {
+		if ctx.GetString(-1) == "panic" {
+			panic("bad request")
+		}
+		ctx.PushString("re:" + ctx.GetString(-1))
+		return 1
+	}
+	slot, err := DukDebugger().newAttachment(nil, nil, nil, nil, nil, request, nil, nil)
+	c.Assert(err, IsNil)
+	dData := slotToPtr(slot)
+	defer goDebugDetachedFunction(s.ctx.duk_context, dData)
+
+	s.ctx.PushString("ping")
+	c.Assert(int(goDebugRequestFunction(s.ctx.duk_context, dData, 1)), Equals, 1)
+	c.Assert(s.ctx.GetString(-1), Equals, "re:ping")
+	s.ctx.Pop2()
+
+	s.ctx.PushString("panic")
+	c.Assert(int(goDebugRequestFunction(s.ctx.duk_context, dData, 1)), Equals, -1)
+	c.Assert(s.ctx.GetString(-1), Equals, "panic: bad request")
+	s.ctx.Pop2()
+}
+
+func (s *DuktapeSuite) TestDebugDetachedFunction(c *C) {
+	var top int
+	detached := func(ctx *Context, uData unsafe.Pointer) {
+		ctx.PushString("detached")
+		top = ctx.GetTop()
+		ctx.Pop()
+	}
+	slot, err := DukDebugger().newAttachment(nil, nil, nil, nil, nil, nil, detached, nil)
+	c.Assert(err, IsNil)
+
+	s.ctx.PushInt(1)
+	goDebugDetachedFunction(s.ctx.duk_context, slotToPtr(slot))
+	c.Assert(top, Equals, 2)
+	_, err = DukDebugger().getAttachment(slot)
+	c.Assert(err, NotNil)
+}
@@ -2980,7 +2980,12 @@ typedef struct duk_hthread duk_context;
 #define DUK_USE_FUNC_NAME_PROPERTY
 #undef DUK_USE_GC_TORTURE
 #undef DUK_USE_GET_MONOTONIC_TIME
-#undef DUK_USE_GET_RANDOM_DOUBLE
+/* go-duktape local patch: Math.random() values come from random.c. Only
+ * usable where the current thread is in scope as 'thr', which the Go random
+ * source throws its panics to.
+ */
+extern double duk_go_random_double(void *thr, void *udata);
+#define DUK_USE_GET_RANDOM_DOUBLE(udata) duk_go_random_double((void *) thr, (udata))
 #define DUK_USE_GLOBAL_BINDING
 #define DUK_USE_GLOBAL_BUILTIN
 #undef DUK_USE_HEAPPTR16
@@ -3078,7 +3083,11 @@ typedef struct duk_hthread duk_context;
 #define DUK_USE_TAILCALL
 #define DUK_USE_TARGET_INFO "unknown"
 #define DUK_USE_TRACEBACKS
-#define DUK_USE_TRACEBACK_DEPTH 10
+/* go-duktape local patch: the traceback depth is set per heap, see
+ * heapdata.h. Only usable where the current thread is in scope as 'thr'.
+ */
+extern int duk_go_traceback_depth(void *udata);
+#define DUK_USE_TRACEBACK_DEPTH duk_go_traceback_depth(thr->heap->heap_udata)
 #define DUK_USE_VALSTACK_GROW_SHIFT 2
 #define DUK_USE_VALSTACK_LIMIT 1000000L
 #define DUK_USE_VALSTACK_SHRINK_CHECK_SHIFT 2
@@ -2337,7 +2337,8 @@ DUK_INTERNAL_DECL const duk_uint8_t duk_builtins_data[4281];
 #define DUK_UTIL_H_INCLUDED
 
 #if defined(DUK_USE_GET_RANDOM_DOUBLE)
-#define DUK_UTIL_GET_RANDOM_DOUBLE(thr) DUK_USE_GET_RANDOM_DOUBLE((thr)->heap_udata)
+/* go-duktape local patch: the heap udata is reached through thr->heap. */
+#define DUK_UTIL_GET_RANDOM_DOUBLE(thr) DUK_USE_GET_RANDOM_DOUBLE((thr)->heap->heap_udata)
 #else
 #define DUK_UTIL_GET_RANDOM_DOUBLE(thr) duk_util_tinyrandom_get_double(thr)
 #endif
@@ -4231,6 +4232,7 @@ struct duk_compiler_instr {
 	duk_instr_t ins;
 #if defined(DUK_USE_PC2LINE)
 	duk_uint32_t line;
+	duk_uint32_t column;  /* go-duktape local patch, see duk__token_column() */
 #endif
 };
 
@@ -4371,6 +4373,15 @@ struct duk_compiler_ctx {
 	/* code emission temporary */
 	duk_int_t emit_jumpslot_pc;
 
+#if defined(DUK_USE_PC2LINE)
+	/* go-duktape local patch: last token column computed, see
+	 * duk__token_column()
+	 */
+	duk_int_t column_line;
+	duk_size_t column_offset;
+	duk_uint32_t column;
+#endif
+
 	/* current function being compiled (embedded instead of pointer for more compact access) */
 	duk_compiler_func curr_func;
 };
@@ -48429,6 +48440,13 @@ DUK_LOCAL void duk__add_compiler_error_line(duk_hthread *thr) {
 		                 at_end ? ", end of input" : "");
 		duk_concat(thr, 2);
 		duk_put_prop_stridx_short(thr, -2, DUK_STRIDX_MESSAGE);
+
+		/* go-duktape local patch: keep the byte offset of the token in
+		 * the source to point at the column of the error, see
+		 * CompileError.
+		 */
+		duk_push_uint(thr, (duk_uint_t) thr->compile_ctx->curr_token.start_offset);
+		duk_put_prop_string(thr, -2, DUK_HIDDEN_SYMBOL("CompileOffset"));
 	} else {
 		duk_pop(thr);
 	}
@@ -56683,9 +56701,19 @@ DUK_INTERNAL void duk_hobject_pc2line_pack(duk_hthread *thr, duk_compiler_instr
 		curr_offset += (duk_uint_fast32_t) be_ctx->offset;
 	}
 
-	/* compact */
-	new_size = (duk_size_t) curr_offset;
+	/* go-duktape local patch: append the column of each instruction as a
+	 * big endian uint32, read by the Go side for source maps.  The line
+	 * lookup only reads the bitstream, so it is not affected.
+	 */
+	new_size = (duk_size_t) curr_offset + (duk_size_t) length * sizeof(duk_uint32_t);
 	duk_hbuffer_resize(thr, h_buf, new_size);
+	{
+		duk_uint8_t *p;
+		p = (duk_uint8_t *) DUK_HBUFFER_DYNAMIC_GET_DATA_PTR(thr->heap, h_buf) + curr_offset;
+		for (curr_pc = 0U; curr_pc < length; curr_pc++) {
+			DUK_RAW_WRITEINC_U32_BE(p, instrs[curr_pc].column);
+		}
+	}
 
 	(void) duk_to_fixed_buffer(thr, -1, NULL);
 
@@ -68886,12 +68914,78 @@ DUK_LOCAL duk_compiler_instr *duk__get_instr_ptr(duk_compiler_ctx *comp_ctx, duk
 	return ((duk_compiler_instr *) (void *) DUK_BW_GET_BASEPTR(comp_ctx->thr, &comp_ctx->curr_func.bw_code)) + pc;
 }
 
+#if defined(DUK_USE_PC2LINE)
+/* go-duktape local patch: the column of a token, in UTF-16 code units from
+ * the start of its line, recorded next to the line of each instruction for
+ * source maps, see duk_hobject_pc2line_pack().  The column is counted from
+ * the previous result when the token is on the same line, so compiling a
+ * long (minified) line stays linear, rewinds included.
+ */
+DUK_LOCAL duk_uint32_t duk__token_column(duk_compiler_ctx *comp_ctx, duk_token *tok) {
+	const duk_uint8_t *input;
+	duk_size_t offset, start, end;
+	duk_uint32_t units;
+	duk_bool_t back;
+
+	if (tok->start_line <= 0) {
+		return 0;  /* prologue, no token yet */
+	}
+
+	input = comp_ctx->lex.input;
+	offset = tok->start_offset;
+	if (offset > comp_ctx->lex.input_length) {
+		offset = comp_ctx->lex.input_length;
+	}
+
+	back = 0;
+	if (tok->start_line == comp_ctx->column_line) {
+		if (offset >= comp_ctx->column_offset) {
+			start = comp_ctx->column_offset;
+			end = offset;
+		} else {
+			start = offset;
+			end = comp_ctx->column_offset;
+			back = 1;
+		}
+	} else {
+		/* Scan back to the line terminator: LF, CR, LS or PS. */
+		start = offset;
+		while (start > 0) {
+			duk_uint8_t x = input[start - 1];
+			if (x == 0x0aU || x == 0x0dU ||
+			    ((x == 0xa8U || x == 0xa9U) && start >= 3 &&
+			     input[start - 3] == 0xe2U && input[start - 2] == 0x80U)) {
+				break;
+			}
+			start--;
+		}
+		end = offset;
+		comp_ctx->column = 0;
+	}
+
+	/* Count the leading bytes; a 4-byte sequence is a surrogate pair. */
+	units = 0;
+	for (; start < end; start++) {
+		duk_uint8_t x = input[start];
+		if ((x & 0xc0U) != 0x80U) {
+			units += (x >= 0xf0U ? 2 : 1);
+		}
+	}
+
+	comp_ctx->column_line = tok->start_line;
+	comp_ctx->column_offset = offset;
+	comp_ctx->column = back ? comp_ctx->column - units : comp_ctx->column + units;
+	return comp_ctx->column;
+}
+#endif  /* DUK_USE_PC2LINE */
+
 /* emit instruction; could return PC but that's not needed in the majority
  * of cases.
  */
 DUK_LOCAL void duk__emit(duk_compiler_ctx *comp_ctx, duk_instr_t ins) {
 #if defined(DUK_USE_PC2LINE)
 	duk_int_t line;
+	duk_token *tok;
 #endif
 	duk_compiler_instr *instr;
 
@@ -68915,15 +69009,17 @@ DUK_LOCAL void duk__emit(duk_compiler_ctx *comp_ctx, duk_instr_t ins) {
 	 * right now.
 	 */
 	/* approximation, close enough */
-	line = comp_ctx->prev_token.start_line;
-	if (line == 0) {
-		line = comp_ctx->curr_token.start_line;
+	tok = &comp_ctx->prev_token;
+	if (tok->start_line == 0) {
+		tok = &comp_ctx->curr_token;
 	}
+	line = tok->start_line;
 #endif
 
 	instr->ins = ins;
 #if defined(DUK_USE_PC2LINE)
 	instr->line = (duk_uint32_t) line;
+	instr->column = duk__token_column(comp_ctx, tok);
 #endif
 #if defined(DUK_USE_DEBUGGER_SUPPORT)
 	if (line < comp_ctx->curr_func.min_line) {
@@ -69462,6 +69558,7 @@ DUK_LOCAL void duk__insert_jump_entry(duk_compiler_ctx *comp_ctx, duk_int_t jump
 	instr->ins = DUK_ENC_OP_ABC(DUK_OP_JUMP, 0);
 #if defined(DUK_USE_PC2LINE)
 	instr->line = (duk_uint32_t) line;
+	instr->column = duk__token_column(comp_ctx, &comp_ctx->curr_token);
 #endif
 
 	DUK_BW_ADD_PTR(comp_ctx->thr, &comp_ctx->curr_func.bw_code, sizeof(duk_compiler_instr));
@@ -75738,6 +75835,17 @@ DUK_LOCAL duk_ret_t duk__js_compile_raw(duk_hthread *thr, void *udata) {
 		duk__advance(comp_ctx);  /* init 'curr_token' */
 		duk__advance_expect(comp_ctx, DUK_TOK_FUNCTION);
 		(void) duk__parse_func_like_raw(comp_ctx, 0 /*flags*/);
+
+		/* go-duktape local patch: the function must be the whole input,
+		 * input after its closing brace (e.g. after a '}' in a Function
+		 * constructor body closing it early) was silently ignored.
+		 */
+		DUK_ASSERT(comp_ctx->curr_token.t == DUK_TOK_RCURLY);
+		duk__advance(comp_ctx);
+		if (comp_ctx->curr_token.t != DUK_TOK_EOF) {
+			DUK_ERROR_SYNTAX(thr, DUK_STR_PARSE_ERROR);
+			DUK_WO_NORETURN(return 0;);
+		}
 	} else {
 		DUK_ASSERT(func->is_function == 0);
 		DUK_ASSERT(is_eval == 0 || is_eval == 1);
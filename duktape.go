@@ -15,23 +15,83 @@ package duktape
 #include "duk_console.h"
 extern duk_ret_t goFunctionCall(duk_context *ctx);
 extern void goFinalizeCall(duk_context *ctx);
+
+// Keep in sync with goFunctionPtrProp and goContextPtrProp.
+#define GO_FUNCTION_PTR_PROP "\xff" "goFunctionPtrProp"
+#define GO_CONTEXT_PTR_PROP "\xff" "goContextPtrProp"
+
+// GO_FUNCTION_RET_THROW is returned by goFunctionCall to throw the value on
+// top of the stack, e.g. the error of a recovered panic. The throw happens
+// here, once the Go frames are gone.
+#define GO_FUNCTION_RET_THROW (-1024)
+
+static duk_ret_t _duk_go_function_call(duk_context *ctx) {
+	duk_ret_t rc = goFunctionCall(ctx);
+	if (rc == GO_FUNCTION_RET_THROW) {
+		return duk_throw(ctx);
+	}
+	return rc;
+}
+
+static duk_idx_t _duk_push_go_function(duk_context *ctx, void *fun_ptr, void *ctx_ptr) {
+	duk_idx_t idx = duk_push_c_function(ctx, _duk_go_function_call, DUK_VARARGS);
+	duk_push_c_function(ctx, (duk_c_function) goFinalizeCall, 1);
+	duk_push_pointer(ctx, fun_ptr);
+	duk_put_prop_string(ctx, -2, GO_FUNCTION_PTR_PROP);
+	duk_push_pointer(ctx, ctx_ptr);
+	duk_put_prop_string(ctx, -2, GO_CONTEXT_PTR_PROP);
+	duk_set_finalizer(ctx, -2);
+
+	duk_push_pointer(ctx, fun_ptr);
+	duk_put_prop_string(ctx, -2, GO_FUNCTION_PTR_PROP);
+	duk_push_pointer(ctx, ctx_ptr);
+	duk_put_prop_string(ctx, -2, GO_CONTEXT_PTR_PROP);
+	return idx;
+}
+
+// names holds count NUL terminated names back to back.
+static void _duk_put_go_functions(duk_context *ctx, duk_idx_t obj_idx, const char *names, duk_size_t *name_lens, void **fun_ptrs, duk_size_t count, void *ctx_ptr) {
+	duk_size_t i;
+	obj_idx = duk_require_normalize_index(ctx, obj_idx);
+	for (i = 0; i < count; i++) {
+		_duk_push_go_function(ctx, fun_ptrs[i], ctx_ptr);
+		duk_put_prop_lstring(ctx, obj_idx, names, name_lens[i]);
+		names += name_lens[i];
+	}
+}
+
+static void _duk_put_numbers(duk_context *ctx, duk_idx_t obj_idx, const char *names, duk_size_t *name_lens, duk_double_t *values, duk_size_t count) {
+	duk_size_t i;
+	obj_idx = duk_require_normalize_index(ctx, obj_idx);
+	for (i = 0; i < count; i++) {
+		duk_push_number(ctx, values[i]);
+		duk_put_prop_lstring(ctx, obj_idx, names, name_lens[i]);
+		names += name_lens[i];
+	}
+}
 */
 import "C"
 import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 	"unsafe"
 )
 
 var reFuncName = regexp.MustCompile("^[a-z_][a-z0-9_]*([A-Z_][a-z0-9_]*)*$")
 
+// Keep in sync with GO_FUNCTION_PTR_PROP and GO_CONTEXT_PTR_PROP.
 const (
 	goFunctionPtrProp = "\xff" + "goFunctionPtrProp"
 	goContextPtrProp  = "\xff" + "goContextPtrProp"
 )
 
+// goFunctionRetThrow is returned by a Go function to throw the value on
+// top of the stack.
+const goFunctionRetThrow = C.GO_FUNCTION_RET_THROW
+
 type Context struct {
 	*context
 }
@@ -47,18 +107,23 @@ type context struct {
 	duk_context *C.duk_context
 	fnIndex     *functionIndex
 	timerIndex  *timerIndex
+	udata       unsafe.Pointer
+
+	random          func() float64
+	normalizeUTF8   bool
+	propagatePanics bool
+	pendingPanic    *pendingPanic
+	goCalls         int // Go functions called by Duktape running, see repanic
+	sourceMaps      map[string]*SourceMap
+
+	globalResolver      func(name string) (interface{}, bool)
+	globalResolverProxy bool
 }
 
 // New returns plain initialized duktape context object
 // See: http://duktape.org/api.html#duk_create_heap_default
 func New() *Context {
-	d := &Context{
-		&context{
-			duk_context: C.duk_create_heap(nil, nil, nil, nil, nil),
-			fnIndex:     newFunctionIndex(),
-			timerIndex:  &timerIndex{},
-		},
-	}
+	d := newContext(nil)
 
 	ctx := d.duk_context
 	C.duk_logging_init(ctx, 0)
@@ -74,6 +139,37 @@ type Flags struct {
 	Logging    uint
 	PrintAlert uint
 	Console    uint
+
+	// Random, when set, provides the values returned by Math.random.
+	// It must return numbers in [0, 1); anything else falls back to the
+	// internal generator. Pass (*rand.Rand).Float64 of a seeded source to
+	// make scripts deterministic, or a crypto backed function for
+	// unpredictable values. It runs in the middle of script execution and
+	// must not call back into the context.
+	Random func() float64
+
+	// NormalizeUTF8 converts the strings passed between Go and the heap:
+	// Go strings are pushed the way scripts represent them and strings
+	// read back are valid UTF-8, see CESU8ToUTF8 and UTF8ToCESU8. It
+	// applies to the string values pushed and read, to the keys of the
+	// *PropString, *PropLstring, *GlobalString and *GlobalLstring
+	// functions, and to the sources of the Compile*, Eval*, Pcompile* and
+	// Peval* functions. Paths, file names and the messages of errors made
+	// from Go are passed as they are. Without it all strings are passed as
+	// they are, byte for byte.
+	NormalizeUTF8 bool
+
+	// TracebackDepth is the number of call stack entries recorded in the
+	// stack of errors, 10 when it is not set.
+	TracebackDepth int
+
+	// PropagatePanics raises the panics of Go functions again in the Go
+	// code which made the outermost protected call, e.g. PevalString, once
+	// the heap is unwound. The protected calls made by Go functions in
+	// between return the Error of the panic. Without it a panic only
+	// becomes an Error thrown to the script. Timer callbacks have no Go
+	// caller, so their panics always stay Errors.
+	PropagatePanics bool
 }
 
 // FlagConsoleProxyWrapper is a Console flag.
@@ -88,13 +184,7 @@ const FlagConsoleFlush = 1 << 1
 // You can control the behaviour of duktape by setting flags.
 // See: http://duktape.org/api.html#duk_create_heap_default
 func NewWithFlags(flags *Flags) *Context {
-	d := &Context{
-		&context{
-			duk_context: C.duk_create_heap(nil, nil, nil, nil, nil),
-			fnIndex:     newFunctionIndex(),
-			timerIndex:  &timerIndex{},
-		},
-	}
+	d := newContext(flags)
 
 	ctx := d.duk_context
 	C.duk_logging_init(ctx, C.duk_uint_t(flags.Logging))
@@ -105,6 +195,24 @@ func NewWithFlags(flags *Flags) *Context {
 	return d
 }
 
+func newContext(flags *Flags) *Context {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
+	ctx := &context{
+		fnIndex:         newFunctionIndex(),
+		timerIndex:      &timerIndex{},
+		random:          flags.Random,
+		normalizeUTF8:   flags.NormalizeUTF8,
+		propagatePanics: flags.PropagatePanics,
+	}
+	ctx.udata = heapData.add(ctx, flags)
+	ctx.duk_context = C.duk_create_heap(nil, nil, nil, ctx.udata, nil)
+
+	return &Context{ctx}
+}
+
 func contextFromPointer(ctx *C.duk_context) *Context {
 	return &Context{&context{duk_context: ctx}}
 }
@@ -131,32 +239,72 @@ func (d *Context) PushGoFunction(fn func(*Context) int) int {
 	funPtr := d.fnIndex.add(fn)
 	ctxPtr := contexts.add(d)
 
-	idx := d.PushCFunction((*[0]byte)(C.goFunctionCall), C.DUK_VARARGS)
-	d.PushCFunction((*[0]byte)(C.goFinalizeCall), 1)
-	d.PushPointer(funPtr)
-	d.PutPropString(-2, goFunctionPtrProp)
-	d.PushPointer(ctxPtr)
-	d.PutPropString(-2, goContextPtrProp)
-	d.SetFinalizer(-2)
+	return int(C._duk_push_go_function(d.duk_context, funPtr, ctxPtr))
+}
+
+// putGoFunctions stores the Go functions fns into the object at objIndex
+// under the matching names, all in one call into C.
+func (d *Context) putGoFunctions(objIndex int, names []string, fns []func(*Context) int) {
+	if len(fns) == 0 {
+		return
+	}
+	ctxPtr := contexts.add(d)
+	funPtrs := make([]unsafe.Pointer, len(fns))
+	for i, fn := range fns {
+		funPtrs[i] = d.fnIndex.add(fn)
+	}
+
+	keys, lens := d.joinKeys(names)
+	__names__, _ := lstring(keys, len(keys))
+	C._duk_put_go_functions(d.duk_context, C.duk_idx_t(objIndex), __names__, &lens[0], &funPtrs[0], C.duk_size_t(len(fns)), ctxPtr)
+}
 
-	d.PushPointer(funPtr)
-	d.PutPropString(-2, goFunctionPtrProp)
-	d.PushPointer(ctxPtr)
-	d.PutPropString(-2, goContextPtrProp)
+// putNumbers is the Go side of duk_put_number_list: it stores values into
+// the object at objIndex under the matching names in one call into C.
+func (d *Context) putNumbers(objIndex int, names []string, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	keys, lens := d.joinKeys(names)
+	__names__, _ := lstring(keys, len(keys))
+	C._duk_put_numbers(d.duk_context, C.duk_idx_t(objIndex), __names__, &lens[0], (*C.duk_double_t)(unsafe.Pointer(&values[0])), C.duk_size_t(len(values)))
+}
 
-	return idx
+// joinKeys concatenates names, converted like the keys of PutPropLstring,
+// and returns their lengths, so keys holding any byte, NUL included, are
+// passed to C whole.
+func (d *Context) joinKeys(names []string) (string, []C.duk_size_t) {
+	var b strings.Builder
+	lens := make([]C.duk_size_t, len(names))
+	for i, name := range names {
+		name = d.heapString(name)
+		b.WriteString(name)
+		lens[i] = C.duk_size_t(len(name))
+	}
+	return b.String(), lens
 }
 
+// goFunctionCall calls the Go function being called by the script. A panic
+// of the function is recovered and thrown to the script as an Error, a panic
+// must not unwind through the C frames of Duktape.
+//
 //export goFunctionCall
-func goFunctionCall(cCtx *C.duk_context) C.duk_ret_t {
+func goFunctionCall(cCtx *C.duk_context) (result C.duk_ret_t) {
 	d := contextFromPointer(cCtx)
 
 	funPtr, ctx := d.getFunctionPtrs()
 	d.transmute(unsafe.Pointer(ctx))
 
-	result := d.fnIndex.get(funPtr)(d)
+	d.goCalls++
+	defer func() {
+		d.goCalls--
+		if r := recover(); r != nil {
+			d.pushPanicError(r)
+			result = goFunctionRetThrow
+		}
+	}()
 
-	return C.duk_ret_t(result)
+	return C.duk_ret_t(d.fnIndex.get(funPtr)(d))
 }
 
 //export goFinalizeCall
@@ -189,17 +337,37 @@ func (d *Context) Destroy() {
 }
 
 type Error struct {
+	// Code is the code of the error class, e.g. ErrType for a TypeError or
+	// an error class inheriting from it, ErrNone for thrown non-errors.
+	Code       ErrorCode
 	Type       string
 	Message    string
 	FileName   string
 	LineNumber int
 	Stack      string
+
+	// GoStack is the stack of the goroutine for errors made of a panic
+	// recovered from a Go function.
+	GoStack string
+
+	// Frames is the call stack recorded when the error was created,
+	// innermost first, see Flags.TracebackDepth.
+	Frames []Frame
+
+	// err is the Go error thrown with ThrowGoError.
+	err error
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Type, e.Message)
 }
 
+// Unwrap returns the Go error the script error was thrown for with
+// ThrowGoError, or nil.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
 type Type uint
 
 func (t Type) IsNone() bool      { return t == TypeNone }
@@ -212,6 +380,7 @@ func (t Type) IsObject() bool    { return t == TypeObject }
 func (t Type) IsBuffer() bool    { return t == TypeBuffer }
 func (t Type) IsPointer() bool   { return t == TypePointer }
 func (t Type) IsLightFunc() bool { return t == TypeLightFunc }
+func (t Type) IsSymbol() bool    { return t == TypeSymbol }
 
 func (t Type) String() string {
 	switch t {
@@ -235,6 +404,8 @@ func (t Type) String() string {
 		return "Pointer"
 	case TypeLightFunc:
 		return "LightFunc"
+	case TypeSymbol:
+		return "Symbol"
 	default:
 		return "Unknown"
 	}
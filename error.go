@@ -0,0 +1,245 @@
+package duktape
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tracedataProp is the hidden property Duktape records the call stack of
+// an error in. Each entry of the stack is a pair of values: a function and
+// its pc combined with flags, or a C file name and line for errors raised
+// inside Duktape.
+const tracedataProp = "\x82Tracedata"
+
+// errorFrames returns the frames of the error at errIndex with the given
+// stack. The stack text holds the names, files and lines, the trace data
+// tells which of the functions are Go functions and the columns.
+func (d *Context) errorFrames(errIndex int, stack string) []Frame {
+	frames := parseStack(stack)
+	if len(frames) == 0 || !d.IsObject(errIndex) {
+		return frames
+	}
+
+	d.GetPropString(errIndex, tracedataProp)
+	defer d.Pop()
+	if !d.IsArray(-1) {
+		return frames
+	}
+
+	n := d.GetLength(-1)
+	for i, j := 0, 0; i < len(frames) && j+1 < n; i, j = i+1, j+2 {
+		d.GetPropIndex(-1, uint(j))
+		if d.IsFunction(-1) {
+			frames[i].Go = d.HasPropString(-1, goFunctionPtrProp)
+			// the pc is in the low 32 bits, flags are above them
+			d.GetPropIndex(-2, uint(j+1))
+			frames[i].PC = int(uint64(d.GetNumber(-1)) & 0xffffffff)
+			d.Pop()
+			if !frames[i].Native {
+				frames[i].Column = d.pcColumn(-1, frames[i].PC)
+			}
+		}
+		d.Pop()
+	}
+	return frames
+}
+
+// parseStack parses the frames of a Duktape stack trace, which look like
+//
+//	at name (file.js:12) strict
+//	at name () native strict preventsyield
+//	at [anon] (duktape.c:123) internal
+func parseStack(stack string) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "at ") {
+			continue
+		}
+		line = line[len("at "):]
+
+		open := strings.Index(line, " (")
+		close := strings.LastIndex(line, ")")
+		if open < 0 || close < open {
+			continue
+		}
+
+		frame := Frame{Function: line[:open]}
+		if frame.Function == "[anon]" {
+			frame.Function = ""
+		}
+
+		location := line[open+2 : close]
+		if i := strings.LastIndex(location, ":"); i >= 0 {
+			if n, err := strconv.Atoi(location[i+1:]); err == nil {
+				frame.Line = n
+				location = location[:i]
+			}
+		}
+		frame.File = location
+
+		for _, flag := range strings.Fields(line[close+1:]) {
+			if flag == "native" || flag == "internal" {
+				frame.Native = true
+			}
+		}
+
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// IsSyntaxError reports whether e is a SyntaxError.
+func (e *Error) IsSyntaxError() bool { return e.Code == ErrSyntax }
+
+// IsTypeError reports whether e is a TypeError.
+func (e *Error) IsTypeError() bool { return e.Code == ErrType }
+
+// IsRangeError reports whether e is a RangeError.
+func (e *Error) IsRangeError() bool { return e.Code == ErrRange }
+
+// IsReferenceError reports whether e is a ReferenceError.
+func (e *Error) IsReferenceError() bool { return e.Code == ErrReference }
+
+// IsEvalError reports whether e is an EvalError.
+func (e *Error) IsEvalError() bool { return e.Code == ErrEval }
+
+// IsURIError reports whether e is a URIError.
+func (e *Error) IsURIError() bool { return e.Code == ErrURI }
+
+// reIdentifier matches the names usable as JavaScript identifiers.
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// errorClassSource makes the constructor of an error class named name
+// inheriting from the error prototype proto. Instances are made by the
+// base constructor, so they carry a stack trace, and then moved to the
+// prototype of the class.
+const errorClassSource = `(function (name, proto) {
+	var Base = proto.constructor;
+	function ErrorClass(message) {
+		var err = new Base(message);
+		Object.setPrototypeOf(err, ErrorClass.prototype);
+		return err;
+	}
+	ErrorClass.prototype = Object.create(proto, {
+		constructor: { value: ErrorClass, writable: true, configurable: true },
+		name: { value: name, writable: true, configurable: true }
+	});
+	Object.setPrototypeOf(ErrorClass, Base);
+	Object.defineProperty(ErrorClass, 'name', { value: name, configurable: true });
+	return ErrorClass;
+})`
+
+// PushErrorClass pushes the constructor of a new error class, inheriting
+// from the class of base, e.g. ErrType for a subclass of TypeError. Store it
+// where scripts find it, e.g. with PutGlobalString, and they can throw and
+// catch its errors with instanceof:
+//
+//	ctx.PushErrorClass("QuotaError", duktape.ErrRange)
+//	ctx.PutGlobalString("QuotaError")
+//
+// Errors of the class report base as Code, and their name is name. Go code
+// makes them by calling the constructor with the message, e.g. with New.
+func (d *Context) PushErrorClass(name string, base ErrorCode) (int, error) {
+	if !reIdentifier.MatchString(name) {
+		return -1, fmt.Errorf("malformed error class name %q", name)
+	}
+	if base.String() == "" {
+		return -1, errors.New("unknown base error code " + strconv.Itoa(int(base)))
+	}
+
+	if err := d.PevalString(errorClassSource); err != nil {
+		d.Pop()
+		return -1, err
+	}
+	d.PushString(name)
+	d.PushErrorObject(int(base), "%s", "")
+	d.GetPrototype(-1)
+	d.Remove(-2)
+	if err := d.castStringToError(d.Pcall(2)); err != nil {
+		d.Pop()
+		return -1, err
+	}
+	return d.GetTopIndex(), nil
+}
+
+// OnErrorCreate sets the hook called for every error created, by scripts
+// and by Duktape itself, before it is thrown, e.g. to add properties to all
+// errors. The error is at errIndex; the value found there when the hook
+// returns is used as the error, so the hook may also replace it. A nil hook
+// removes it. The hook is installed as Duktape.errCreate.
+func (d *Context) OnErrorCreate(hook func(c *Context, errIndex int)) {
+	d.setErrorHook("errCreate", hook)
+}
+
+// OnErrorThrow sets the hook called for every value about to be thrown, with
+// the value at errIndex, e.g. to log throws. Not all the values are errors:
+// scripts can throw anything. Like for OnErrorCreate, the value found at
+// errIndex when the hook returns is thrown. A nil hook removes it. The hook
+// is installed as Duktape.errThrow.
+func (d *Context) OnErrorThrow(hook func(c *Context, errIndex int)) {
+	d.setErrorHook("errThrow", hook)
+}
+
+// setErrorHook sets the hook as the Duktape.errCreate or Duktape.errThrow
+// callback. Duktape calls them protected, and ignores errors thrown while
+// the hook runs.
+func (d *Context) setErrorHook(name string, hook func(c *Context, errIndex int)) {
+	d.GetGlobalString("Duktape")
+	defer d.Pop()
+
+	if hook == nil {
+		d.DelPropString(-1, name)
+		return
+	}
+	d.PushGoFunction(func(c *Context) int {
+		hook(c, 0)
+		c.Dup(0)
+		return 1
+	})
+	d.PutPropString(-2, name)
+}
+
+// Format implements fmt.Formatter. The %+v verb prints the error followed
+// by its frames, one function per line with the location indented below,
+// like a Go stack trace does.
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, e.Error())
+		if s.Flag('+') {
+			for _, frame := range e.Frames {
+				io.WriteString(s, "\n"+frame.String())
+			}
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(%T=%s)", verb, e, e.Error())
+	}
+}
+
+// String formats the frame like a frame of a Go stack trace.
+func (f Frame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "[anon]"
+	}
+
+	var location string
+	switch {
+	case f.Go:
+		location = "<go>"
+	case f.File == "":
+		location = "<native>"
+	default:
+		location = f.File + ":" + strconv.Itoa(f.Line)
+	}
+	return name + "\n\t" + location
+}
@@ -0,0 +1,204 @@
+package duktape
+
+import (
+	"errors"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestErrorFrames(c *C) {
+	s.ctx.PushGlobalGoFunction("host", func(ctx *Context) int {
+		return ErrRetType
+	})
+	s.ctx.Pop()
+
+	s.ctx.PushString("app.js")
+	err := s.ctx.PcompileStringFilename(0, "function caller() {\n  host();\n}\ncaller();")
+	c.Assert(err, IsNil)
+	err = s.ctx.castStringToError(s.ctx.Pcall(0))
+
+	frames := err.(*Error).Frames
+	c.Assert(frames, HasLen, 3)
+	c.Assert(frames[0], Equals, Frame{Native: true, Go: true})
+	c.Assert(frames[1].Function, Equals, "caller")
+	c.Assert(frames[1].File, Equals, "app.js")
+	c.Assert(frames[1].Line, Equals, 2)
+	c.Assert(frames[1].PC > 0, Equals, true)
+	c.Assert(frames[2].Function, Equals, "global")
+	c.Assert(frames[2].Line, Equals, 4)
+
+	c.Assert(fmt.Sprintf("%v", err), Equals, "TypeError: error (rc -6)")
+	c.Assert(fmt.Sprintf("%d", err), Equals, "%!d(*duktape.Error=TypeError: error (rc -6))")
+	c.Assert(fmt.Sprintf("%+v", err), Equals, `TypeError: error (rc -6)
+[anon]
+	<go>
+caller
+	app.js:2
+global
+	app.js:4`)
+}
+
+func (s *DuktapeSuite) TestErrorFramesNative(c *C) {
+	err := s.ctx.PevalString(`[1].map(function inner() { null.x; })`)
+	frames := err.(*Error).Frames
+	c.Assert(frames, HasLen, 4)
+
+	// errors raised inside Duktape blame its C source first
+	c.Assert(frames[0].Native, Equals, true)
+	c.Assert(frames[0].Function, Equals, "")
+	c.Assert(frames[1].Function, Equals, "inner")
+	c.Assert(frames[2].Function, Equals, "map")
+	c.Assert(frames[2].Native, Equals, true)
+	c.Assert(frames[2].Go, Equals, false)
+	c.Assert(frames[2].File, Equals, "")
+}
+
+func (s *DuktapeSuite) TestTracebackDepth(c *C) {
+	src := `function rec(n) { if (n == 0) throw new Error('deep'); rec(n - 1); } rec(30)`
+
+	err := s.ctx.PevalString(src)
+	c.Assert(len(err.(*Error).Frames) <= 10, Equals, true)
+
+	ctx := NewWithFlags(&Flags{TracebackDepth: 50})
+	defer ctx.DestroyHeap()
+	err = ctx.PevalString(src)
+	c.Assert(err.(*Error).Frames, HasLen, 32)
+	c.Assert(err.(*Error).Frames[31].Function, Equals, "eval")
+}
+
+func (s *DuktapeSuite) TestErrorCode(c *C) {
+	c.Assert(ErrorCode(ErrType).String(), Equals, "TypeError")
+	c.Assert(ErrorCode(ErrNone).String(), Equals, "")
+
+	var syntaxErr *Error
+	err := s.ctx.PevalString(`var x = ;`)
+	c.Assert(errors.As(err, &syntaxErr), Equals, true)
+	c.Assert(syntaxErr.Code, Equals, ErrorCode(ErrSyntax))
+	c.Assert(syntaxErr.IsSyntaxError(), Equals, true)
+	c.Assert(syntaxErr.IsTypeError(), Equals, false)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`undefined.x`)
+	c.Assert(err.(*Error).IsTypeError(), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`missing`)
+	c.Assert(err.(*Error).IsReferenceError(), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`throw 'not an error'`)
+	c.Assert(err.(*Error).Code, Equals, ErrorCode(ErrNone))
+	s.ctx.Pop()
+
+	// the codes are both int and ErrorCode values
+	err = s.ctx.Safe().Errorf(ErrUnimplemented, "not %s", "yet")
+	c.Assert(err, ErrorMatches, ".*not yet")
+	var code ErrorCode = ErrURI
+	s.ctx.PushErrorObject(ErrURI, "%s", "bad")
+	c.Assert(s.ctx.GetErrorCode(-1) == ErrURI, Equals, true)
+	c.Assert(ErrorCode(s.ctx.GetErrorCode(-1)) == code, Equals, true)
+	s.ctx.Pop()
+}
+
+func (s *DuktapeSuite) TestPushErrorClass(c *C) {
+	_, err := s.ctx.PushErrorClass("QuotaError", ErrRange)
+	c.Assert(err, IsNil)
+	s.ctx.PutGlobalString("QuotaError")
+
+	err = s.ctx.PevalString(`
+		var caught;
+		try {
+			throw new QuotaError('too many');
+		} catch (e) {
+			caught = [e instanceof QuotaError, e instanceof RangeError, e instanceof Error,
+				e.name, e.message, String(e), QuotaError.name, typeof e.stack].join(',');
+		}
+		caught;
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,true,true,QuotaError,too many,QuotaError: too many,QuotaError,string")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`throw QuotaError('from a call')`)
+	c.Assert(err, ErrorMatches, "QuotaError: from a call")
+	c.Assert(err.(*Error).Code, Equals, ErrorCode(ErrRange))
+	c.Assert(err.(*Error).IsRangeError(), Equals, true)
+	s.ctx.Pop()
+
+	// made from Go
+	s.ctx.GetGlobalString("QuotaError")
+	s.ctx.PushString("from go")
+	c.Assert(s.ctx.Safe().New(1), IsNil)
+	c.Assert(s.ctx.GetErrorCode(-1), Equals, ErrRange)
+	c.Assert(s.ctx.SafeToString(-1), Equals, "QuotaError: from go")
+	s.ctx.Pop()
+
+	_, err = s.ctx.PushErrorClass("not valid", ErrType)
+	c.Assert(err, NotNil)
+	_, err = s.ctx.PushErrorClass("Valid", ErrNone)
+	c.Assert(err, NotNil)
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
+
+func (s *DuktapeSuite) TestOnErrorCreate(c *C) {
+	s.ctx.OnErrorCreate(func(ctx *Context, errIndex int) {
+		if ctx.IsError(errIndex) {
+			ctx.PushString("req-42")
+			ctx.PutPropString(errIndex, "requestId")
+		}
+	})
+
+	err := s.ctx.PevalString(`
+		var ids = [];
+		try { null.x; } catch (e) { ids.push(e.requestId); }
+		try { throw new Error('own'); } catch (e) { ids.push(e.requestId); }
+		ids.join(',');
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "req-42,req-42")
+	s.ctx.Pop()
+
+	s.ctx.PushErrorObject(ErrType, "%s", "from go")
+	s.ctx.GetPropString(-1, "requestId")
+	c.Assert(s.ctx.GetString(-1), Equals, "req-42")
+	s.ctx.Pop2()
+
+	s.ctx.OnErrorCreate(nil)
+	err = s.ctx.PevalString(`typeof new Error('x').requestId`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "undefined")
+}
+
+func (s *DuktapeSuite) TestOnErrorThrow(c *C) {
+	var thrown []string
+	s.ctx.OnErrorThrow(func(ctx *Context, errIndex int) {
+		ctx.Dup(errIndex)
+		thrown = append(thrown, ctx.SafeToString(-1))
+		ctx.Pop()
+	})
+
+	err := s.ctx.PevalString(`
+		try { throw 'plain value'; } catch (e) {}
+		try { undefined.x; } catch (e) {}
+		throw new RangeError('last');
+	`)
+	c.Assert(err, ErrorMatches, "RangeError: last")
+	c.Assert(thrown, HasLen, 3)
+	c.Assert(thrown[0], Equals, "plain value")
+	c.Assert(thrown[1], Matches, "TypeError: .*")
+	c.Assert(thrown[2], Equals, "RangeError: last")
+	s.ctx.Pop()
+
+	s.ctx.OnErrorThrow(func(ctx *Context, errIndex int) {
+		panic("hook failure")
+	})
+	err = s.ctx.PevalString(`throw new Error('original')`)
+	c.Assert(err, NotNil)
+	s.ctx.Pop()
+
+	s.ctx.OnErrorThrow(nil)
+	thrown = nil
+	s.ctx.PevalString(`throw 1`)
+	c.Assert(thrown, HasLen, 0)
+}
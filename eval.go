@@ -0,0 +1,134 @@
+package duktape
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// EvalOptions are the options of Evaluate.
+type EvalOptions struct {
+	// Filename is the file name of the code in errors and stack traces,
+	// "eval" when empty.
+	Filename string
+	// LineOffset is the number of lines preceding the code in its file,
+	// e.g. 9 for a script starting at line 10 of a template.
+	LineOffset int
+	// Strict evaluates the code in strict mode, as if it started with a
+	// 'use strict' directive.
+	Strict bool
+	// Shebang ignores a "#!" comment on the first line of the code.
+	Shebang bool
+}
+
+// Evaluate evaluates src like PevalString, with the file name and position
+// of opts, and pushes the result, or the error, to the stack. The lines in
+// the errors, stack traces and CompileError are the ones in the host file.
+func (d *Context) Evaluate(src string, opts EvalOptions) error {
+	// Duktape only accepts a shebang at the very start of the source, which
+	// the lines of LineOffset move away, so it is cut here instead. The line
+	// break is kept to keep the lines of the code.
+	if opts.Shebang && strings.HasPrefix(src, "#!") {
+		if i := strings.IndexByte(src, '\n'); i >= 0 {
+			src = src[i:]
+		} else {
+			src = ""
+		}
+	}
+	// Duktape has no line offset, empty lines before the code do the same.
+	if opts.LineOffset > 0 {
+		src = strings.Repeat("\n", opts.LineOffset) + src
+	}
+
+	flags := CompileEval | CompileNoSource
+	if opts.Strict {
+		flags |= CompileStrict
+	}
+	filename := opts.Filename
+	if filename == "" {
+		filename = "eval"
+	}
+
+	d.PushString(filename)
+	if err := d.PcompileStringFilename(flags, src); err != nil {
+		return err
+	}
+	d.PushGlobalObject()
+	return d.castStringToError(d.PcallMethod(0))
+}
+
+// EvalWithScope runs src as the body of a function taking the names of vars
+// as parameters, called with the values of vars converted by PushGoValue,
+// and pushes what the function returns, or the error, to the stack:
+//
+//	ctx.EvalWithScope("return price * (1 + rate);", map[string]interface{}{
+//		"price": 100,
+//		"rate":  0.2,
+//	})
+//
+// The variables are local to the call, so they neither need to be written
+// into the source nor end up in the global object. A src that does not
+// compile as a function body, e.g. with a '}' closing the function early,
+// returns a *CompileError located in src. Nothing is pushed when
+// a name is not an identifier or a value can not be converted.
+func (d *Context) EvalWithScope(src string, vars map[string]interface{}) error {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if !reIdentifier.MatchString(name) {
+			return fmt.Errorf("malformed variable name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// The code starts on the line of the header, to keep its line numbers.
+	// The names are identifiers, so only src can end the function early,
+	// and the compiler rejects anything following the function.
+	header := "function (" + strings.Join(names, ", ") + ") {"
+	top := d.GetTop()
+	d.PushString("eval")
+	if err := d.PcompileStringFilename(CompileFunction|CompileNoSource, header+src+"\n}"); err != nil {
+		if ce, ok := err.(*CompileError); ok {
+			trimHeader(ce, len(header))
+			clampEnd(ce, src)
+		}
+		return err
+	}
+
+	for _, name := range names {
+		if err := d.PushGoValue(vars[name]); err != nil {
+			d.SetTop(top)
+			return fmt.Errorf("variable %s: %v", name, err)
+		}
+	}
+	return d.castStringToError(d.Pcall(len(names)))
+}
+
+// trimHeader removes the header of the wrapping function, n ASCII bytes,
+// from the first line of a syntax error in the code.
+func trimHeader(ce *CompileError, n int) {
+	if ce.Line != 1 || ce.Column <= n || len(ce.Source) < n {
+		return
+	}
+	ce.Column -= n
+	ce.Source = ce.Source[n:]
+	if len(ce.Caret) > n {
+		ce.Caret = ce.Caret[n:]
+	}
+}
+
+// clampEnd moves a syntax error found in the closing of the wrapping
+// function, e.g. at the end of an unterminated src, to the end of the last
+// line of src.
+func clampEnd(ce *CompileError, src string) {
+	lines := strings.Count(src, "\n") + 1
+	if ce.Line <= lines {
+		return
+	}
+	last := src[strings.LastIndexByte(src, '\n')+1:]
+	ce.Line = lines
+	ce.Source = strings.TrimSuffix(last, "\r")
+	ce.Column = utf8.RuneCountInString(ce.Source) + 1
+	ce.Caret = indent(ce.Source) + "^"
+}
@@ -0,0 +1,142 @@
+package duktape
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestEvaluate(c *C) {
+	err := s.ctx.Evaluate("1 + 2", EvalOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 3)
+	s.ctx.Pop()
+
+	err = s.ctx.Evaluate("var a = 1;\nnull.x;", EvalOptions{Filename: "page.html", LineOffset: 10})
+	e := err.(*Error)
+	c.Assert(e.Type, Equals, "TypeError")
+	c.Assert(e.FileName, Equals, "page.html")
+	c.Assert(e.LineNumber, Equals, 12)
+	c.Assert(strings.Contains(e.Stack, "page.html:12"), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.Evaluate("var a = 1;\n  var = 2;", EvalOptions{Filename: "page.html", LineOffset: 10})
+	ce := err.(*CompileError)
+	c.Assert(ce.Filename, Equals, "page.html")
+	c.Assert(ce.Line, Equals, 12)
+	c.Assert(ce.Column, Equals, 7)
+	c.Assert(ce.Source, Equals, "  var = 2;")
+	s.ctx.Pop()
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
+
+func (s *DuktapeSuite) TestEvaluateStrict(c *C) {
+	src := `(function() { return this === undefined; })()`
+	c.Assert(s.ctx.Evaluate(src, EvalOptions{}), IsNil)
+	c.Assert(s.ctx.GetBoolean(-1), Equals, false)
+	c.Assert(s.ctx.Evaluate(src, EvalOptions{Strict: true}), IsNil)
+	c.Assert(s.ctx.GetBoolean(-1), Equals, true)
+	s.ctx.Pop2()
+
+	err := s.ctx.Evaluate(`with ({}) {}`, EvalOptions{Strict: true})
+	c.Assert(err, FitsTypeOf, &CompileError{})
+}
+
+func (s *DuktapeSuite) TestEvaluateShebang(c *C) {
+	src := "#!/usr/bin/env duk\nthrow new Error('fail');"
+	err := s.ctx.Evaluate(src, EvalOptions{})
+	c.Assert(err, FitsTypeOf, &CompileError{})
+	s.ctx.Pop()
+
+	err = s.ctx.Evaluate(src, EvalOptions{Shebang: true, LineOffset: 3})
+	c.Assert(err, ErrorMatches, "Error: fail")
+	c.Assert(err.(*Error).LineNumber, Equals, 5)
+	s.ctx.Pop()
+
+	c.Assert(s.ctx.Evaluate("#!/usr/bin/env duk", EvalOptions{Shebang: true}), IsNil)
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+}
+
+func (s *DuktapeSuite) TestEvalWithScope(c *C) {
+	err := s.ctx.EvalWithScope("return price * (1 + rate) + unit;", map[string]interface{}{
+		"price": 100,
+		"rate":  0.5,
+		"unit":  " EUR",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "150 EUR")
+	s.ctx.Pop()
+
+	err = s.ctx.EvalWithScope("var leaked = items.length; return items[1].name;", map[string]interface{}{
+		"items": []map[string]interface{}{{"name": "a"}, {"name": "b"}},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "b")
+	s.ctx.Pop()
+	s.ctx.PushGlobalObject()
+	c.Assert(s.ctx.HasPropString(-1, "leaked"), Equals, false)
+	s.ctx.Pop()
+
+	c.Assert(s.ctx.EvalWithScope("return 1;", nil), IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 1)
+	s.ctx.Pop()
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
+
+func (s *DuktapeSuite) TestEvalWithScopeErrors(c *C) {
+	err := s.ctx.EvalWithScope("return x.y;", map[string]interface{}{"x": nil})
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	c.Assert(err.(*Error).LineNumber, Equals, 1)
+	s.ctx.Pop()
+
+	err = s.ctx.EvalWithScope("return x +;", map[string]interface{}{"x": 1})
+	ce := err.(*CompileError)
+	c.Assert(ce.Line, Equals, 1)
+	c.Assert(ce.Column, Equals, 11)
+	c.Assert(ce.Source, Equals, "return x +;")
+	c.Assert(ce.Caret, Equals, "          ^")
+	s.ctx.Pop()
+
+	err = s.ctx.EvalWithScope("return (", nil)
+	ce = err.(*CompileError)
+	c.Assert(ce.Line, Equals, 1)
+	c.Assert(ce.Column, Equals, 9)
+	c.Assert(ce.Source, Equals, "return (")
+	c.Assert(ce.Caret, Equals, "        ^")
+	s.ctx.Pop()
+
+	err = s.ctx.EvalWithScope("var a = 1;\n  return (a", nil)
+	ce = err.(*CompileError)
+	c.Assert(ce.Line, Equals, 2)
+	c.Assert(ce.Column, Equals, 12)
+	c.Assert(ce.Source, Equals, "  return (a")
+	s.ctx.Pop()
+
+	for _, src := range []string{
+		"return 1; }; this.pwn = 42; (function(){",
+		"}); this.pwn = 42; (function(){ return x",
+		"return 7; } function zzz() {",
+	} {
+		err = s.ctx.EvalWithScope(src, map[string]interface{}{"x": 5})
+		c.Assert(err, FitsTypeOf, &CompileError{}, Commentf("%s", src))
+		c.Assert(err.(*CompileError).Line, Equals, 1)
+		s.ctx.Pop()
+	}
+	s.ctx.PushGlobalObject()
+	c.Assert(s.ctx.HasPropString(-1, "pwn"), Equals, false)
+	s.ctx.Pop()
+
+	// the Function constructor compiles the same way
+	c.Assert(s.ctx.PevalString(`new Function("}); this.pwn = 42; (function(){")`), NotNil)
+	s.ctx.Pop()
+	s.ctx.PushGlobalObject()
+	c.Assert(s.ctx.HasPropString(-1, "pwn"), Equals, false)
+	s.ctx.Pop()
+
+	err = s.ctx.EvalWithScope("return 1;", map[string]interface{}{"a b": 1})
+	c.Assert(err, ErrorMatches, `malformed variable name "a b"`)
+
+	err = s.ctx.EvalWithScope("return 1;", map[string]interface{}{"a": 1, "ch": make(chan int)})
+	c.Assert(err, ErrorMatches, "variable ch: .*")
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
@@ -0,0 +1,60 @@
+package duktape
+
+// SetGlobalResolver sets fn to resolve global identifiers which are not
+// defined yet. The first time a script refers to an unknown global name fn
+// is called with it; when it returns true the value, converted with
+// PushGoValue, is stored as a global property, so later accesses find it
+// without calling fn again. Names fn does not know, or whose value can not
+// be converted, stay undefined and raise a ReferenceError as usual.
+//
+// This lets a host expose many Go functions while only registering the
+// ones a script actually uses. It works by replacing the global object with
+// a Proxy of it, so each global lookup costs a call into Go. Calling it
+// again replaces fn, nil disables the resolution.
+func (d *Context) SetGlobalResolver(fn func(name string) (interface{}, bool)) {
+	d.globalResolver = fn
+	if d.globalResolverProxy {
+		return
+	}
+	d.globalResolverProxy = true
+
+	d.PushGlobalObject()
+	d.PushProxy(-1, &globalResolver{d.context})
+	d.SetGlobalObject()
+	d.Pop()
+}
+
+type globalResolver struct {
+	ctx *context
+}
+
+// resolve defines key on the real global object, at index 0, if it is
+// missing and the resolver knows it.
+func (r *globalResolver) resolve(c *Context, key string) bool {
+	if c.HasPropString(0, key) {
+		return true
+	}
+	fn := r.ctx.globalResolver
+	if fn == nil {
+		return false
+	}
+	value, ok := fn(key)
+	if !ok {
+		return false
+	}
+	if err := c.PushGoValue(value); err != nil {
+		c.Pop()
+		return false
+	}
+	c.PutPropString(0, key)
+	return true
+}
+
+func (r *globalResolver) Has(c *Context, key string) bool {
+	return r.resolve(c, key)
+}
+
+func (r *globalResolver) Get(c *Context, key string) int {
+	r.resolve(c, key)
+	return 0
+}
@@ -0,0 +1,58 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestSetGlobalResolver(c *C) {
+	calls := map[string]int{}
+	s.ctx.SetGlobalResolver(func(name string) (interface{}, bool) {
+		calls[name]++
+		switch name {
+		case "double":
+			return func(ctx *Context) int {
+				ctx.PushNumber(ctx.GetNumber(0) * 2)
+				return 1
+			}, true
+		case "version":
+			return "1.0", true
+		}
+		return nil, false
+	})
+
+	err := s.ctx.PevalString(`double(2) + double(3) + version + version`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "101.01.0")
+	c.Assert(calls, DeepEquals, map[string]int{"double": 1, "version": 1})
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`typeof missing`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "undefined")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`missing()`)
+	c.Assert(err.(*Error).Type, Equals, "ReferenceError")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`var local = 5; Math.max(local, this.local * 2)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetNumber(-1), Equals, 10.0)
+}
+
+func (s *DuktapeSuite) TestSetGlobalResolver_Replace(c *C) {
+	s.ctx.SetGlobalResolver(func(name string) (interface{}, bool) {
+		return 1, name == "one"
+	})
+	s.ctx.SetGlobalResolver(func(name string) (interface{}, bool) {
+		return 2, name == "two"
+	})
+
+	err := s.ctx.PevalString(`typeof one + typeof two`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "undefinednumber")
+	s.ctx.Pop()
+
+	s.ctx.SetGlobalResolver(nil)
+	err = s.ctx.PevalString(`two + (typeof three)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "2undefined")
+}
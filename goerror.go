@@ -0,0 +1,117 @@
+package duktape
+
+/*
+#include "duktape.h"
+extern duk_ret_t goFinalizeError(duk_context *ctx);
+
+// Keep in sync with goErrorPtrProp.
+#define GO_ERROR_PTR_PROP "\xff" "goErrorPtrProp"
+
+// _duk_attach_go_error stores ptr, the key of a Go error, in the error
+// object at obj_idx, with a finalizer releasing it.
+static void _duk_attach_go_error(duk_context *ctx, duk_idx_t obj_idx, void *ptr) {
+	obj_idx = duk_normalize_index(ctx, obj_idx);
+	duk_push_pointer(ctx, ptr);
+	duk_put_prop_string(ctx, obj_idx, GO_ERROR_PTR_PROP);
+	duk_push_c_function(ctx, goFinalizeError, 1);
+	duk_set_finalizer(ctx, obj_idx);
+}
+*/
+import "C"
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Keep in sync with GO_ERROR_PTR_PROP.
+const goErrorPtrProp = "\xff" + "goErrorPtrProp"
+
+// goErrors holds the Go errors thrown with ThrowGoError until the error
+// objects carrying them are finalized.
+var goErrors = &callbackIndex{
+	callbacks: make(map[unsafe.Pointer]interface{}),
+}
+
+// ThrowGoError makes a Go function fail with err. It pushes an Error with
+// the message of err and returns the value the Go function must return to
+// throw it:
+//
+//	return ctx.ThrowGoError(err)
+//
+// The name of the Error is the name of the type of err when it is exported,
+// e.g. PathError for *fs.PathError, and Error otherwise. The *Error returned
+// for it by the protected calls, e.g. PevalString, unwraps to err, so
+// errors.Is and errors.As see through the script. For err being an *Error
+// the class, name and message are kept, which rethrows a script error as it
+// was: a TypeError is still a TypeError, and an error of a class made with
+// PushErrorClass is an instance of its base class with the name of the class.
+//
+// A nil err pushes nothing and returns 0.
+func (d *Context) ThrowGoError(err error) int {
+	if err == nil {
+		return 0
+	}
+	d.PushGoError(err)
+	return goFunctionRetThrow
+}
+
+// PushGoError pushes the Error ThrowGoError throws for err, without
+// throwing it.
+func (d *Context) PushGoError(err error) {
+	code, name, message := ErrorCode(ErrError), goErrorName(err), err.Error()
+	if e, ok := err.(*Error); ok {
+		name, message = e.Type, e.Message
+		if e.Code.String() != "" {
+			code = e.Code
+		}
+	}
+
+	d.PushErrorObject(int(code), "%s", message)
+	if name != code.String() {
+		d.PushString(name)
+		d.PutPropString(-2, "name")
+	}
+	C._duk_attach_go_error(d.duk_context, -1, goErrors.add(err))
+}
+
+// goError returns the Go error attached to the value at index by
+// PushGoError, or nil.
+func (d *Context) goError(index int) error {
+	if !d.IsObject(index) {
+		return nil
+	}
+	d.GetPropString(index, goErrorPtrProp)
+	ptr := d.GetPointer(-1)
+	d.Pop()
+	if ptr == nil {
+		return nil
+	}
+	err, _ := goErrors.get(ptr).(error)
+	return err
+}
+
+// goErrorName returns the name of the type of err, if it is exported.
+func goErrorName(err error) string {
+	t := reflect.TypeOf(err)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r, _ := utf8.DecodeRuneInString(t.Name()); !unicode.IsUpper(r) {
+		return "Error"
+	}
+	return t.Name()
+}
+
+//export goFinalizeError
+func goFinalizeError(cCtx *C.duk_context) C.duk_ret_t {
+	d := contextFromPointer(cCtx)
+	d.GetPropString(0, goErrorPtrProp)
+	if ptr := d.GetPointer(-1); ptr != nil {
+		goErrors.delete(ptr)
+	}
+	d.Pop()
+	d.DelPropString(0, goErrorPtrProp)
+	return 0
+}
@@ -0,0 +1,110 @@
+package duktape
+
+import (
+	"errors"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type QuotaError struct {
+	Limit int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota of %d exceeded", e.Limit)
+}
+
+var errNotFound = errors.New("not found")
+
+func (s *DuktapeSuite) TestThrowGoError(c *C) {
+	s.ctx.PushGlobalGoFunction("find", func(ctx *Context) int {
+		return ctx.ThrowGoError(fmt.Errorf("find %q: %w", ctx.SafeToString(0), errNotFound))
+	})
+	s.ctx.PushGlobalGoFunction("spend", func(ctx *Context) int {
+		return ctx.ThrowGoError(&QuotaError{Limit: 10})
+	})
+	s.ctx.Pop2()
+
+	err := s.ctx.PevalString(`find('key')`)
+	c.Assert(err, ErrorMatches, `Error: find "key": not found`)
+	c.Assert(errors.Is(err, errNotFound), Equals, true)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`
+		try {
+			spend();
+		} catch (e) {
+			if (!(e instanceof Error) || e.name !== 'QuotaError') {
+				throw new Error('unexpected ' + e);
+			}
+			throw e;
+		}
+	`)
+	var quota *QuotaError
+	c.Assert(errors.As(err, &quota), Equals, true)
+	c.Assert(quota.Limit, Equals, 10)
+	c.Assert(err, ErrorMatches, "QuotaError: quota of 10 exceeded")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`throw new Error('plain')`)
+	c.Assert(errors.Unwrap(err), IsNil)
+	s.ctx.Pop()
+}
+
+func (s *DuktapeSuite) TestThrowGoErrorRethrow(c *C) {
+	s.ctx.PushGlobalGoFunction("rethrow", func(ctx *Context) int {
+		err := ctx.PevalString(`null.x`)
+		return ctx.ThrowGoError(err)
+	})
+	s.ctx.Pop()
+
+	err := s.ctx.PevalString(`rethrow()`)
+	c.Assert(err, FitsTypeOf, &Error{})
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	c.Assert(err.(*Error).Code, Equals, ErrorCode(ErrType))
+	inner, ok := errors.Unwrap(err).(*Error)
+	c.Assert(ok, Equals, true)
+	c.Assert(inner.Message, Equals, err.(*Error).Message)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`try { rethrow(); } catch (e) { [e instanceof TypeError, e.name].join(); }`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,TypeError")
+	s.ctx.Pop()
+
+	_, err = s.ctx.PushErrorClass("QuotaError", ErrRange)
+	c.Assert(err, IsNil)
+	s.ctx.PutGlobalString("QuotaError")
+	s.ctx.PushGlobalGoFunction("rethrowQuota", func(ctx *Context) int {
+		return ctx.ThrowGoError(ctx.PevalString(`throw new QuotaError('full')`))
+	})
+	s.ctx.Pop()
+	err = s.ctx.PevalString(`try { rethrowQuota(); } catch (e) { [e instanceof RangeError, e.name, e.message].join(); }`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,QuotaError,full")
+	s.ctx.Pop()
+	err = s.ctx.PevalString(`rethrowQuota()`)
+	c.Assert(err.(*Error).IsRangeError(), Equals, true)
+}
+
+func (s *DuktapeSuite) TestThrowGoErrorReleased(c *C) {
+	s.ctx.PushGlobalGoFunction("fail", func(ctx *Context) int {
+		return ctx.ThrowGoError(errNotFound)
+	})
+	s.ctx.Pop()
+
+	goErrors.RLock()
+	before := len(goErrors.callbacks)
+	goErrors.RUnlock()
+
+	err := s.ctx.PevalString(`for (var i = 0; i < 10; i++) { try { fail(); } catch (e) {} }`)
+	c.Assert(err, IsNil)
+	s.ctx.Gc(0)
+
+	goErrors.RLock()
+	after := len(goErrors.callbacks)
+	goErrors.RUnlock()
+	c.Assert(after, Equals, before)
+	c.Assert(s.ctx.ThrowGoError(nil), Equals, 0)
+}
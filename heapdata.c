@@ -0,0 +1,26 @@
+#include <stdint.h>
+#include <stdlib.h>
+#include "heapdata.h"
+
+void *duk_go_heapdata_new(uint64_t seed, int go_random, int traceback_depth) {
+	duk_go_heapdata *h;
+
+	h = (duk_go_heapdata *) malloc(sizeof(duk_go_heapdata));
+	if (h == NULL) {
+		return NULL;
+	}
+	duk_go_random_seed(h, seed);
+	h->go_random = go_random;
+	h->traceback_depth = traceback_depth > 0 ? traceback_depth : DUK_GO_TRACEBACK_DEPTH;
+
+	return (void *) h;
+}
+
+int duk_go_traceback_depth(void *udata) {
+	duk_go_heapdata *h = (duk_go_heapdata *) udata;
+
+	if (h == NULL) {
+		return DUK_GO_TRACEBACK_DEPTH;
+	}
+	return h->traceback_depth;
+}
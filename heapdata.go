@@ -0,0 +1,65 @@
+package duktape
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+#include "heapdata.h"
+*/
+import "C"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// heapDataIndex keeps the Go side of the heap udata, see heapdata.h: the
+// context of each heap by its udata.
+type heapDataIndex struct {
+	heaps map[unsafe.Pointer]*context
+	sync.RWMutex
+}
+
+var heapData = &heapDataIndex{
+	heaps: make(map[unsafe.Pointer]*context),
+}
+
+var heapDataSeq uint64
+
+// add allocates the heap udata of ctx, a new heap created with flags.
+func (i *heapDataIndex) add(ctx *context, flags *Flags) unsafe.Pointer {
+	var goRandom C.int
+	if flags.Random != nil {
+		goRandom = 1
+	}
+
+	// the sequence number keeps heaps created at the same instant apart
+	seed := uint64(time.Now().UnixNano()) + atomic.AddUint64(&heapDataSeq, 1)
+	ptr := C.duk_go_heapdata_new(C.uint64_t(seed), goRandom, C.int(flags.TracebackDepth))
+	if ptr == nil {
+		panic("[duktape] Cannot allocate heap data")
+	}
+
+	i.Lock()
+	i.heaps[ptr] = ctx
+	i.Unlock()
+
+	return ptr
+}
+
+// get returns the context of the heap with the given udata.
+func (i *heapDataIndex) get(ptr unsafe.Pointer) *context {
+	i.RLock()
+	ctx := i.heaps[ptr]
+	i.RUnlock()
+
+	return ctx
+}
+
+func (i *heapDataIndex) delete(ptr unsafe.Pointer) {
+	i.Lock()
+	delete(i.heaps, ptr)
+	i.Unlock()
+
+	C.free(ptr)
+}
@@ -0,0 +1,33 @@
+#ifndef DUK_GO_HEAPDATA_H_INCLUDED
+#define DUK_GO_HEAPDATA_H_INCLUDED
+
+#include <stdint.h>
+
+/*
+ *  Per-heap settings, every heap created by go-duktape gets one of these as
+ *  its heap udata.
+ */
+
+#define DUK_GO_TRACEBACK_DEPTH 10
+
+typedef struct {
+	/* Math.random() state, see random.c. */
+	uint64_t random_state[2];
+	int go_random;
+
+	/* Traceback depth, see DUK_USE_TRACEBACK_DEPTH in duk_config.h. */
+	int traceback_depth;
+} duk_go_heapdata;
+
+extern void *duk_go_heapdata_new(uint64_t seed, int go_random, int traceback_depth);
+extern int duk_go_traceback_depth(void *udata);
+
+/* Returned by goRandomDouble when the Go random source panicked and the
+ * error to throw is on the value stack.
+ */
+#define DUK_GO_RANDOM_THROW (-2.0)
+
+extern void duk_go_random_seed(duk_go_heapdata *h, uint64_t seed);
+extern double duk_go_random_double(void *thr, void *udata);
+
+#endif  /* DUK_GO_HEAPDATA_H_INCLUDED */
@@ -0,0 +1,186 @@
+package duktape
+
+/*
+#include "duktape.h"
+*/
+import "C"
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// ValueInfo is the internal information Duktape reports for a value, see
+// InspectValue. The sizes are in bytes, and the fields which do not apply
+// to the value, e.g. HeapBytes of a number, are -1.
+type ValueInfo struct {
+	// Type is the public type of the value, the one GetType reports.
+	Type Type
+	// Tag is the internal tag of the value.
+	Tag int
+	// Pointer is the address of heap allocated values: strings, objects
+	// and buffers; nil for the others.
+	Pointer unsafe.Pointer
+	// Refcount is the reference count of heap allocated values.
+	Refcount int
+	// Class is the internal class number of objects, e.g. 1 for Object,
+	// 2 for Array and 3 for Function.
+	Class int
+	// HeapBytes is the size of the heap allocated header and, for strings
+	// and fixed buffers, the data following it.
+	HeapBytes int
+	// PropertyBytes is the size of the property table of objects.
+	PropertyBytes int
+	// EntrySize, EntryNext, ArraySize and HashSize describe the property
+	// table layout of objects.
+	EntrySize int
+	EntryNext int
+	ArraySize int
+	HashSize  int
+	// BytecodeBytes is the size of the bytecode of compiled functions.
+	BytecodeBytes int
+	// DynamicBytes is the size of the data of dynamic buffers.
+	DynamicBytes int
+	// ThreadState is the state of thread objects.
+	ThreadState int
+	// Variant tells string and buffer variants apart, e.g. fixed, dynamic
+	// and external buffers.
+	Variant int
+}
+
+// InspectValue returns the internal information Duktape has about the value
+// at index, such as its reference count and memory use. It is meant for
+// debugging and diagnostics: the details vary between Duktape versions.
+//
+// See: http://duktape.org/api.html#duk_inspect_value
+func (d *Context) InspectValue(index int) ValueInfo {
+	typ := d.GetType(index)
+	C.duk_inspect_value(d.duk_context, C.duk_idx_t(index))
+	defer d.Pop()
+
+	d.GetPropString(-1, "hptr")
+	ptr := d.GetPointer(-1)
+	d.Pop()
+
+	return ValueInfo{
+		Type:          typ,
+		Tag:           d.getIntProp(-1, "itag"),
+		Pointer:       ptr,
+		Refcount:      d.getIntProp(-1, "refc"),
+		Class:         d.getIntProp(-1, "class"),
+		HeapBytes:     d.getIntProp(-1, "hbytes"),
+		PropertyBytes: d.getIntProp(-1, "pbytes"),
+		EntrySize:     d.getIntProp(-1, "esize"),
+		EntryNext:     d.getIntProp(-1, "enext"),
+		ArraySize:     d.getIntProp(-1, "asize"),
+		HashSize:      d.getIntProp(-1, "hsize"),
+		BytecodeBytes: d.getIntProp(-1, "bcbytes"),
+		DynamicBytes:  d.getIntProp(-1, "dbytes"),
+		ThreadState:   d.getIntProp(-1, "tstate"),
+		Variant:       d.getIntProp(-1, "variant"),
+	}
+}
+
+// Frame is a single activation of a call stack.
+type Frame struct {
+	// Function is the name of the function, empty for anonymous ones.
+	Function string
+	// File is the file name the function was compiled with, empty for
+	// native functions.
+	File string
+	// Line is the line being executed, 0 for native functions.
+	Line int
+	// Column is the column being executed, starting at 0 and counted in
+	// UTF-16 code units like the columns of source maps. Duktape tracks it
+	// per instruction, by the token the instruction was compiled at.
+	Column int
+	// PC is the bytecode offset being executed.
+	PC int
+	// Native is set for functions implemented in C, Go functions included.
+	Native bool
+	// Go is set for functions pushed with PushGoFunction.
+	Go bool
+}
+
+// Callstack returns the activations of the call stack, innermost first.
+// Called from a Go function the first frame is that Go function and the
+// second one the function which called it. Outside of any call the result
+// is empty. Frames in files with a registered source map point at the
+// original sources, see RegisterSourceMap.
+//
+// See: http://duktape.org/api.html#duk_inspect_callstack_entry
+func (d *Context) Callstack() []Frame {
+	var frames []Frame
+	for level := -1; ; level-- {
+		C.duk_inspect_callstack_entry(d.duk_context, C.duk_int_t(level))
+		if d.IsUndefined(-1) {
+			d.Pop()
+			d.mapFrames(frames)
+			return frames
+		}
+
+		frame := Frame{
+			PC:   d.getIntProp(-1, "pc"),
+			Line: d.getIntProp(-1, "lineNumber"),
+		}
+		d.GetPropString(-1, "function")
+		if d.IsFunction(-1) || d.IsLightfunc(-1) {
+			frame.Native = d.IsCFunction(-1) || d.IsLightfunc(-1)
+			frame.Go = d.HasPropString(-1, goFunctionPtrProp)
+			frame.Function = d.getStringProp(-1, "name")
+			frame.File = d.getStringProp(-1, "fileName")
+		}
+		if frame.Native {
+			frame.Line = 0
+		} else {
+			frame.Column = d.pcColumn(-1, frame.PC)
+		}
+		d.Pop2()
+
+		frames = append(frames, frame)
+	}
+}
+
+// pc2lineProp is the hidden property of compiled functions holding their
+// pc to line table.
+const pc2lineProp = "\x82Pc2line"
+
+// pcColumn returns the column of the instruction at pc of the function at
+// funcIndex, or 0 if it is not known. A local patch of Duktape appends the
+// column of each instruction, as a big endian uint32, to the pc to line
+// table, which starts with the number of instructions.
+func (d *Context) pcColumn(funcIndex, pc int) int {
+	d.GetPropString(funcIndex, pc2lineProp)
+	defer d.Pop()
+
+	ptr, size := d.GetBuffer(-1)
+	if ptr == nil || size < 4 {
+		return 0
+	}
+	table := unsafe.Slice((*byte)(ptr), size)
+	n := uint(*(*uint32)(ptr))
+	if pc < 0 || uint(pc) >= n || n*4 > size-4 {
+		return 0
+	}
+	column := table[size-4*n+4*uint(pc):]
+	return int(binary.BigEndian.Uint32(column))
+}
+
+// getIntProp returns the integer property key of the object at objIndex,
+// or -1 if it is missing.
+func (d *Context) getIntProp(objIndex int, key string) int {
+	defer d.Pop()
+	if !d.GetPropString(objIndex, key) || !d.IsNumber(-1) {
+		return -1
+	}
+	return d.GetInt(-1)
+}
+
+// getStringProp returns the string property key of the object at objIndex,
+// or "" if it is missing or not a string.
+func (d *Context) getStringProp(objIndex int, key string) string {
+	defer d.Pop()
+	if !d.GetPropString(objIndex, key) || !d.IsString(-1) {
+		return ""
+	}
+	return d.GetString(-1)
+}
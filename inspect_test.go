@@ -0,0 +1,63 @@
+package duktape
+
+import (
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestInspectValue(c *C) {
+	s.ctx.PushNumber(1)
+	info := s.ctx.InspectValue(-1)
+	c.Assert(info.Type, Equals, TypeNumber)
+	c.Assert(info.Pointer, Equals, unsafe.Pointer(nil))
+	c.Assert(info.Refcount, Equals, -1)
+	c.Assert(info.HeapBytes, Equals, -1)
+
+	err := s.ctx.PevalString(`var shared = [1, 2, 3]; var other = shared; shared`)
+	c.Assert(err, IsNil)
+	info = s.ctx.InspectValue(-1)
+	c.Assert(info.Type, Equals, TypeObject)
+	c.Assert(info.Pointer, Equals, s.ctx.GetHeapptr(-1))
+	c.Assert(info.Class, Equals, 2)
+	c.Assert(info.Refcount >= 3, Equals, true)
+	c.Assert(info.HeapBytes > 0, Equals, true)
+	c.Assert(info.ArraySize >= 3, Equals, true)
+
+	s.ctx.PushString("some string")
+	info = s.ctx.InspectValue(-1)
+	c.Assert(info.Type, Equals, TypeString)
+	c.Assert(info.HeapBytes > len("some string"), Equals, true)
+	c.Assert(s.ctx.GetTop(), Equals, 3)
+}
+
+func (s *DuktapeSuite) TestCallstack(c *C) {
+	c.Assert(s.ctx.Callstack(), HasLen, 0)
+
+	var frames []Frame
+	s.ctx.PushGlobalGoFunction("host", func(ctx *Context) int {
+		frames = ctx.Callstack()
+		return 0
+	})
+	s.ctx.Pop()
+
+	s.ctx.PushString("app.js")
+	err := s.ctx.PcompileStringFilename(0, "function caller() {\n  host();\n}\ncaller();")
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.Pcall(0), Equals, ExecSuccess)
+
+	c.Assert(len(frames) >= 3, Equals, true)
+	c.Assert(frames[0].Go, Equals, true)
+	c.Assert(frames[0].Native, Equals, true)
+	c.Assert(frames[0].Line, Equals, 0)
+
+	c.Assert(frames[1].Function, Equals, "caller")
+	c.Assert(frames[1].File, Equals, "app.js")
+	c.Assert(frames[1].Line, Equals, 2)
+	c.Assert(frames[1].Native, Equals, false)
+	c.Assert(frames[1].Go, Equals, false)
+
+	c.Assert(frames[2].File, Equals, "app.js")
+	c.Assert(frames[2].Line, Equals, 4)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
@@ -0,0 +1,145 @@
+package duktape
+
+// Formats accepted by EncodeOptions.Format.
+const (
+	FormatJSON = "json"
+	FormatJX   = "jx"
+	FormatJC   = "jc"
+)
+
+// EncodeOptions controls the output of EncodeJSON.
+type EncodeOptions struct {
+	// Format is one of FormatJSON (the default), FormatJX or FormatJC.
+	Format string
+
+	// Indent is used as the `space` argument of JSON.stringify: when it is
+	// not empty the output is pretty printed using it for each level.
+	Indent string
+
+	// Replacer is called like a JSON.stringify replacer function, with
+	// the key at index 0 and the value at index 1. It must push the value
+	// to be encoded instead and return 1.
+	Replacer func(*Context) int
+
+	// Keys restricts the encoded object properties to the listed names,
+	// like an array replacer does. It is ignored when Replacer is set.
+	Keys []string
+}
+
+// JxEncode encodes the value at index into JX, the extended custom format
+// of Duktape, replacing it in place. JX keeps undefined, NaN, infinities,
+// buffers and functions, so values can be dumped without losing them.
+//
+// See: http://duktape.org/guide.html#jx
+func (d *Context) JxEncode(index int) string {
+	return d.encodeWith(FormatJX, index)
+}
+
+// JxDecode decodes the JX string at index, replacing it in place.
+//
+// See: http://duktape.org/guide.html#jx
+func (d *Context) JxDecode(index int) {
+	d.decodeWith(FormatJX, index)
+}
+
+// JcEncode encodes the value at index into JC, the extended compatible
+// format of Duktape, replacing it in place. JC output is valid JSON which
+// represents the values plain JSON can not as tagged strings or objects.
+//
+// See: http://duktape.org/guide.html#jc
+func (d *Context) JcEncode(index int) string {
+	return d.encodeWith(FormatJC, index)
+}
+
+// JcDecode decodes the JC string at index, replacing it in place. Since JC
+// is valid JSON this behaves like JsonDecode: tagged values such as
+// {"_nan":true} are not converted back.
+//
+// See: http://duktape.org/guide.html#jc
+func (d *Context) JcDecode(index int) {
+	d.decodeWith(FormatJC, index)
+}
+
+// EncodeJSON encodes the value at index in the format set by opts and
+// returns the result, leaving the stack untouched. A nil opts encodes
+// plain compact JSON. Errors thrown while encoding (e.g. for circular
+// structures or from the replacer) are returned as *Error.
+func (d *Context) EncodeJSON(index int, opts *EncodeOptions) (string, error) {
+	if opts == nil {
+		opts = &EncodeOptions{}
+	}
+	index = d.NormalizeIndex(index)
+
+	format := opts.Format
+	if format == "" {
+		format = FormatJSON
+	}
+	if format == FormatJSON {
+		d.GetGlobalString("JSON")
+		d.GetPropString(-1, "stringify")
+		d.Remove(-2)
+	} else {
+		d.pushDuktapeMethod("enc")
+		d.PushString(format)
+	}
+	d.Dup(index)
+
+	switch {
+	case opts.Replacer != nil:
+		d.PushGoFunction(opts.Replacer)
+	case len(opts.Keys) > 0:
+		d.PushArray()
+		for i, key := range opts.Keys {
+			d.PushString(key)
+			d.PutPropIndex(-2, uint(i))
+		}
+	default:
+		d.PushUndefined()
+	}
+
+	if opts.Indent != "" {
+		d.PushString(opts.Indent)
+	} else {
+		d.PushUndefined()
+	}
+
+	nargs := 3
+	if format != FormatJSON {
+		nargs = 4
+	}
+	if err := d.castStringToError(d.Pcall(nargs)); err != nil {
+		d.Pop()
+		return "", err
+	}
+
+	result := d.GetString(-1)
+	d.Pop()
+	return result, nil
+}
+
+func (d *Context) encodeWith(format string, index int) string {
+	index = d.NormalizeIndex(index)
+	d.pushDuktapeMethod("enc")
+	d.PushString(format)
+	d.Dup(index)
+	d.Call(2)
+	d.Replace(index)
+	return d.GetString(index)
+}
+
+func (d *Context) decodeWith(format string, index int) {
+	index = d.NormalizeIndex(index)
+	d.pushDuktapeMethod("dec")
+	d.PushString(format)
+	d.Dup(index)
+	d.Call(2)
+	d.Replace(index)
+}
+
+// pushDuktapeMethod pushes the function stored as name on the Duktape
+// built-in object.
+func (d *Context) pushDuktapeMethod(name string) {
+	d.GetGlobalString("Duktape")
+	d.GetPropString(-1, name)
+	d.Remove(-2)
+}
@@ -0,0 +1,67 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestJxEncode(c *C) {
+	s.ctx.PevalString(`({u: undefined, n: NaN, i: -Infinity, b: Uint8Array.allocPlain(2), f: function foo() {}})`)
+	c.Assert(s.ctx.JxEncode(-1), Equals, `{u:undefined,n:NaN,i:-Infinity,b:|0000|,f:{_func:true}}`)
+	c.Assert(s.ctx.IsString(-1), Equals, true)
+
+	s.ctx.JxDecode(-1)
+	s.ctx.GetPropString(-1, "n")
+	c.Assert(s.ctx.IsNan(-1), Equals, true)
+	s.ctx.Pop()
+	s.ctx.GetPropString(-1, "b")
+	c.Assert(s.ctx.IsBuffer(-1), Equals, true)
+	s.ctx.Pop()
+	s.ctx.GetPropString(-1, "u")
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+	c.Assert(s.ctx.GetTop(), Equals, 2)
+}
+
+func (s *DuktapeSuite) TestJcEncode(c *C) {
+	s.ctx.PevalString(`({u: undefined, n: NaN, b: Uint8Array.allocPlain(1)})`)
+	c.Assert(s.ctx.JcEncode(-1), Equals, `{"u":{"_undef":true},"n":{"_nan":true},"b":{"_buf":"00"}}`)
+
+	// JC is plain JSON, so decoding keeps the tagged values as they are
+	s.ctx.JcDecode(-1)
+	s.ctx.GetPropString(-1, "n")
+	s.ctx.GetPropString(-1, "_nan")
+	c.Assert(s.ctx.GetBoolean(-1), Equals, true)
+}
+
+func (s *DuktapeSuite) TestEncodeJSON(c *C) {
+	s.ctx.PevalString(`({a: 1, b: [1, 2], c: "x"})`)
+
+	str, err := s.ctx.EncodeJSON(-1, nil)
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, `{"a":1,"b":[1,2],"c":"x"}`)
+
+	str, err = s.ctx.EncodeJSON(-1, &EncodeOptions{Indent: "  ", Keys: []string{"a", "c"}})
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, "{\n  \"a\": 1,\n  \"c\": \"x\"\n}")
+
+	str, err = s.ctx.EncodeJSON(-1, &EncodeOptions{
+		Format: FormatJX,
+		Replacer: func(ctx *Context) int {
+			if ctx.GetString(0) == "c" {
+				ctx.PushUndefined()
+			} else {
+				ctx.Dup(1)
+			}
+			return 1
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, `{a:1,b:[1,2],c:undefined}`)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestEncodeJSON_Error(c *C) {
+	s.ctx.PevalString(`var a = {}; a.a = a; a`)
+
+	_, err := s.ctx.EncodeJSON(-1, &EncodeOptions{Format: FormatJC})
+	c.Assert(err, NotNil)
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
@@ -0,0 +1,91 @@
+package duktape
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// MemoryFunctions holds the allocation functions of a heap, as C function
+// pointers, and the heap udata passed to them.
+type MemoryFunctions struct {
+	Alloc   unsafe.Pointer
+	Realloc unsafe.Pointer
+	Free    unsafe.Pointer
+	Udata   unsafe.Pointer
+}
+
+// ErrHeapBufferFreed is returned when resizing a HeapBuffer after Free.
+var ErrHeapBufferFreed = errors.New("heap buffer is freed")
+
+// HeapBuffer is a block of memory allocated with the allocator of a heap,
+// e.g. to hand over to C code which frees it with duk_free. The memory is
+// not managed by the Go garbage collector: call Free once it is no longer
+// needed, and before the heap is destroyed.
+type HeapBuffer struct {
+	ctx  *Context
+	ptr  unsafe.Pointer
+	size int
+}
+
+// NewHeapBuffer allocates size bytes with Alloc. The contents of the memory
+// are undefined.
+func (d *Context) NewHeapBuffer(size int) (*HeapBuffer, error) {
+	b := &HeapBuffer{ctx: d}
+	if err := b.Resize(size); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Pointer returns the address of the memory, nil for an empty or freed
+// buffer.
+func (b *HeapBuffer) Pointer() unsafe.Pointer {
+	return b.ptr
+}
+
+// Len returns the size of the buffer in bytes.
+func (b *HeapBuffer) Len() int {
+	return b.size
+}
+
+// Bytes returns the memory of the buffer as a byte slice. The slice is only
+// valid until the next Resize or Free.
+func (b *HeapBuffer) Bytes() []byte {
+	if b.ptr == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(b.ptr), b.size)
+}
+
+// Resize changes the size of the buffer with Realloc, keeping the contents
+// up to the smaller of the old and the new size.
+func (b *HeapBuffer) Resize(size int) error {
+	if b.ctx == nil {
+		return ErrHeapBufferFreed
+	}
+	if size < 0 {
+		return errors.New("negative heap buffer size")
+	}
+
+	if size == 0 {
+		b.ctx.Free(b.ptr)
+		b.ptr, b.size = nil, 0
+		return nil
+	}
+
+	ptr := b.ctx.Realloc(b.ptr, size)
+	if ptr == nil {
+		return errors.New("cannot allocate heap buffer")
+	}
+	b.ptr, b.size = ptr, size
+	return nil
+}
+
+// Free releases the memory. It is safe to call Free more than once.
+func (b *HeapBuffer) Free() {
+	if b.ctx == nil {
+		return
+	}
+	b.ctx.Free(b.ptr)
+	b.ctx, b.ptr, b.size = nil, nil, 0
+}
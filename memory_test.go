@@ -0,0 +1,76 @@
+package duktape
+
+import (
+	"testing"
+	"unsafe"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestAllocReallocFree(c *C) {
+	ptr := s.ctx.Alloc(16)
+	c.Assert(ptr, Not(Equals), unsafe.Pointer(nil))
+	ptr = s.ctx.Realloc(ptr, 64)
+	c.Assert(ptr, Not(Equals), unsafe.Pointer(nil))
+	s.ctx.Free(ptr)
+
+	ptr = s.ctx.AllocRaw(16)
+	c.Assert(ptr, Not(Equals), unsafe.Pointer(nil))
+	ptr = s.ctx.ReallocRaw(ptr, 64)
+	c.Assert(ptr, Not(Equals), unsafe.Pointer(nil))
+	s.ctx.FreeRaw(ptr)
+
+	s.ctx.Free(nil)
+}
+
+func (s *DuktapeSuite) TestGetMemoryFunctions(c *C) {
+	funcs := s.ctx.GetMemoryFunctions()
+	c.Assert(funcs.Alloc, Not(Equals), unsafe.Pointer(nil))
+	c.Assert(funcs.Realloc, Not(Equals), unsafe.Pointer(nil))
+	c.Assert(funcs.Free, Not(Equals), unsafe.Pointer(nil))
+	c.Assert(funcs.Udata, Equals, s.ctx.udata)
+}
+
+func (s *DuktapeSuite) TestHeapBuffer(c *C) {
+	b, err := s.ctx.NewHeapBuffer(4)
+	c.Assert(err, IsNil)
+	c.Assert(b.Len(), Equals, 4)
+	c.Assert(b.Pointer(), Not(Equals), unsafe.Pointer(nil))
+	copy(b.Bytes(), "abcd")
+
+	c.Assert(b.Resize(8), IsNil)
+	c.Assert(b.Len(), Equals, 8)
+	c.Assert(string(b.Bytes()[:4]), Equals, "abcd")
+
+	c.Assert(b.Resize(2), IsNil)
+	c.Assert(string(b.Bytes()), Equals, "ab")
+
+	c.Assert(b.Resize(0), IsNil)
+	c.Assert(b.Bytes(), IsNil)
+	c.Assert(b.Pointer(), Equals, unsafe.Pointer(nil))
+
+	b.Free()
+	b.Free()
+	c.Assert(b.Len(), Equals, 0)
+	c.Assert(b.Resize(1), Equals, ErrHeapBufferFreed)
+
+	_, err = s.ctx.NewHeapBuffer(-1)
+	c.Assert(err, ErrorMatches, "negative heap buffer size")
+}
+
+func (s *DuktapeSuite) TestHeapBufferLarge(c *C) {
+	if testing.Short() {
+		c.Skip("allocates 1 GiB")
+	}
+	size := 1<<30 + 16
+	b, err := s.ctx.NewHeapBuffer(size)
+	if err != nil {
+		c.Skip("cannot allocate 1 GiB: " + err.Error())
+	}
+	defer b.Free()
+
+	buf := b.Bytes()
+	c.Assert(buf, HasLen, size)
+	buf[size-1] = 42
+	c.Assert(buf[size-1], Equals, byte(42))
+}
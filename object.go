@@ -0,0 +1,38 @@
+package duktape
+
+// RangeObject calls fn for each key of the object at index, as enumerated
+// with the given Enum* flags, stopping early if fn returns false.
+//
+// When fn is called the value of the key is on top of the stack, at index
+// -1. Values fn pushes go above it, so the value then stays at its absolute
+// index, c.GetTopIndex() on entry to fn, and whatever fn leaves behind is
+// removed before the next key. Symbol keys, see EnumIncludeSymbols, are
+// passed in their internal representation: push one back and use GetSymbol
+// to inspect it. The stack is restored to its original height when
+// RangeObject returns, including when fn panics.
+func (d *Context) RangeObject(index int, flags uint, fn func(key string, c *Context) bool) {
+	index = d.NormalizeIndex(index)
+	top := d.GetTop()
+	defer d.SetTop(top)
+
+	d.Enum(index, flags)
+	enumIndex := d.GetTopIndex()
+	for d.Next(enumIndex, true) {
+		key := d.rawKey(-2)
+		// keep the value at a stable position: [ ... enum value ]
+		d.Remove(-2)
+		if !fn(key, d) {
+			return
+		}
+		d.SetTop(enumIndex + 1)
+	}
+}
+
+// rawKey returns the key at index: the internal representation of symbols
+// and GetString for the others.
+func (d *Context) rawKey(index int) string {
+	if d.IsSymbol(index) {
+		return d.rawString(index)
+	}
+	return d.GetString(index)
+}
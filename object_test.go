@@ -0,0 +1,60 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestRangeObject(c *C) {
+	s.ctx.PevalString(`({a: 1, b: "two", c: [3]})`)
+
+	var keys []string
+	var values []string
+	s.ctx.RangeObject(-1, 0, func(key string, ctx *Context) bool {
+		keys = append(keys, key)
+		values = append(values, ctx.SafeToString(-1))
+		ctx.PushString("garbage")
+		return true
+	})
+
+	c.Assert(keys, DeepEquals, []string{"a", "b", "c"})
+	c.Assert(values, DeepEquals, []string{"1", "two", "3"})
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestRangeObject_Stop(c *C) {
+	s.ctx.PevalString(`[10, 20, 30]`)
+
+	var keys []string
+	s.ctx.RangeObject(-1, EnumArrayIndicesOnly, func(key string, ctx *Context) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+
+	c.Assert(keys, DeepEquals, []string{"0", "1"})
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestRangeObject_Flags(c *C) {
+	s.ctx.PevalString(`var o = Object.create({inherited: true}); Object.defineProperty(o, "hidden", {value: 1}); o.own = 2; o`)
+
+	var keys []string
+	s.ctx.RangeObject(-1, EnumOwnPropertiesOnly|EnumIncludeNonenumerable, func(key string, ctx *Context) bool {
+		keys = append(keys, key)
+		return true
+	})
+	c.Assert(keys, DeepEquals, []string{"hidden", "own"})
+}
+
+func (s *DuktapeSuite) TestRangeObject_Panic(c *C) {
+	s.ctx.PevalString(`({a: 1})`)
+
+	func() {
+		defer func() {
+			c.Assert(recover(), Equals, "boom")
+		}()
+		s.ctx.RangeObject(-1, 0, func(key string, ctx *Context) bool {
+			ctx.PushObject()
+			panic("boom")
+		})
+	}()
+
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
@@ -0,0 +1,64 @@
+package duktape
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// goStackProp is the property of the errors made of recovered Go panics
+// which holds the stack of the panicking goroutine.
+const goStackProp = "goStack"
+
+// pendingPanic is a recovered panic waiting to be raised again, see
+// Flags.PropagatePanics.
+type pendingPanic struct {
+	value interface{}
+}
+
+// pushPanicError pushes an Error for the recovered panic value r, with the
+// stack of the goroutine in its goStack property. With PropagatePanics the
+// panic is kept to be raised again once the heap is unwound.
+func (d *Context) pushPanicError(r interface{}) {
+	d.recordPanic(r)
+	d.pushPanicValue(r)
+}
+
+// recordPanic keeps the recovered panic value r to be raised again if the
+// heap propagates panics and no other panic is pending.
+func (c *context) recordPanic(r interface{}) {
+	if c.propagatePanics && c.pendingPanic == nil {
+		c.pendingPanic = &pendingPanic{value: r}
+	}
+}
+
+// pushPanicValue pushes the Error of pushPanicError without recording r.
+func (d *Context) pushPanicValue(r interface{}) {
+	d.PushErrorObject(ErrError, "%s", fmt.Sprint("panic: ", r))
+	d.PushString(string(debug.Stack()))
+	d.PutPropString(-2, goStackProp)
+}
+
+// repanic raises the pending panic, if any, again. It is called once
+// control returns to Go from a protected call, and only raises the panic
+// when no Go function called by Duktape is running, so in the Go code which
+// made the outermost protected call. The protected calls made by the Go
+// functions in between get the Error.
+func (d *Context) repanic() {
+	if d.pendingPanic == nil || d.goCalls > 0 {
+		return
+	}
+	p := d.pendingPanic
+	d.pendingPanic = nil
+	panic(p.value)
+}
+
+// timerPcall calls the timer callback on top of the stack. No Go code waits
+// for the result of timers to raise their panics in, so the panics of the
+// Go functions they call stay the Errors thrown to the script.
+func (d *Context) timerPcall() int {
+	d.goCalls++
+	result := d.Pcall(0)
+	d.goCalls--
+	d.pendingPanic = nil
+	return result
+}
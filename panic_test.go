@@ -0,0 +1,168 @@
+package duktape
+
+import (
+	"errors"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestGoFunctionPanic(c *C) {
+	s.ctx.PushGlobalGoFunction("explode", func(ctx *Context) int {
+		panic("boom")
+	})
+	s.ctx.Pop()
+
+	err := s.ctx.PevalString(`
+		try {
+			explode();
+		} catch (e) {
+			[e instanceof Error, e.message, e.goStack.indexOf('panic_test.go') >= 0].join(',');
+		}
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "true,panic: boom,true")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`explode()`)
+	c.Assert(err, FitsTypeOf, &Error{})
+	e := err.(*Error)
+	c.Assert(e.Type, Equals, "Error")
+	c.Assert(e.Message, Equals, "panic: boom")
+	c.Assert(strings.Contains(e.GoStack, "panic_test.go"), Equals, true)
+	s.ctx.Pop()
+
+	// the heap is still usable
+	err = s.ctx.PevalString(`1 + 1`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 2)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestPropagatePanics(c *C) {
+	ctx := NewWithFlags(&Flags{PropagatePanics: true})
+	defer ctx.DestroyHeap()
+
+	value := errors.New("boom")
+	ctx.PushGlobalGoFunction("explode", func(ctx *Context) int {
+		panic(value)
+	})
+	var nestedErr error
+	ctx.PushGlobalGoFunction("nested", func(ctx *Context) int {
+		nestedErr = ctx.PevalString(`explode()`)
+		return 0
+	})
+	ctx.Pop2()
+
+	for _, src := range []string{
+		`explode()`,
+		`try { explode() } catch (e) { 'caught' }`,
+		`nested()`,
+	} {
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			ctx.PevalString(src)
+		}()
+		c.Assert(recovered, Equals, value, Commentf("%s", src))
+		ctx.SetTop(0)
+	}
+	// the panic is only raised in the outermost call, the Go function in
+	// between gets the Error
+	c.Assert(nestedErr, ErrorMatches, "Error: panic: boom")
+
+	func() {
+		defer func() { c.Assert(recover(), Equals, value) }()
+		ctx.GetGlobalString("explode")
+		ctx.Pcall(0)
+	}()
+	ctx.SetTop(0)
+
+	err := ctx.PevalString(`'still' + ' usable'`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "still usable")
+}
+
+func (s *DuktapeSuite) TestPropagatePanicsTimer(c *C) {
+	ctx := NewWithFlags(&Flags{PropagatePanics: true})
+	defer ctx.DestroyHeap()
+
+	ch := make(chan string, 1)
+	ctx.PushTimers()
+	ctx.PushGlobalGoFunction("explode", func(ctx *Context) int {
+		panic("boom")
+	})
+	ctx.PushGlobalGoFunction("report", func(ctx *Context) int {
+		ch <- ctx.SafeToString(0)
+		return 0
+	})
+	ctx.Pop2()
+
+	err := ctx.PevalString(`
+		setTimeout(explode, 0);
+		setTimeout(function () {
+			try { explode(); } catch (e) { report(e.message); }
+		}, 5);
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(<-ch, Equals, "panic: boom")
+
+	ctx.Lock()
+	defer ctx.Unlock()
+	err = ctx.PevalString(`'still' + ' usable'`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "still usable")
+}
+
+func (s *DuktapeSuite) TestCallbackPanics(c *C) {
+	ctx := NewWithFlags(&Flags{Random: func() float64 { panic("no entropy") }})
+	defer ctx.DestroyHeap()
+
+	err := ctx.PevalString(`try { Math.random(); } catch (e) { e.message }`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "panic: no entropy")
+	ctx.Pop()
+
+	func() {
+		defer func() { c.Assert(recover(), ErrorMatches, "Error: panic: no entropy") }()
+		ctx.Random()
+	}()
+	c.Assert(ctx.GetTop(), Equals, 0)
+
+	// the pivots of Array.prototype.sort come from the random source
+	calls := 0
+	sorting := NewWithFlags(&Flags{Random: func() float64 {
+		if calls++; calls > 3 {
+			panic("no entropy")
+		}
+		return 0.5
+	}})
+	defer sorting.DestroyHeap()
+	err = sorting.PevalString(`var a = [];
+		for (var i = 0; i < 64; i++) a.push(64 - i);
+		try { a.sort(function(x, y) { return x - y; }); 'sorted' } catch (e) { e.message }`)
+	c.Assert(err, IsNil)
+	c.Assert(sorting.GetString(-1), Equals, "panic: no entropy")
+	c.Assert(calls, Equals, 4)
+	err = sorting.PevalString(`a.length`)
+	c.Assert(err, IsNil)
+	c.Assert(sorting.GetInt(-1), Equals, 64)
+
+	ctx.PushString("abc")
+	var seen []rune
+	func() {
+		defer func() { c.Assert(recover(), Equals, "decode") }()
+		ctx.DecodeString(-1, func(r rune) {
+			seen = append(seen, r)
+			panic("decode")
+		})
+	}()
+	c.Assert(seen, DeepEquals, []rune{'a'})
+
+	func() {
+		defer func() { c.Assert(recover(), Equals, "map") }()
+		ctx.MapString(-1, func(r rune) rune { panic("map") })
+	}()
+	ctx.MapString(-1, func(r rune) rune { return r - 'a' + 'A' })
+	c.Assert(ctx.GetString(-1), Equals, "ABC")
+}
@@ -0,0 +1,88 @@
+package duktape
+
+// PropertyDescriptor describes an own property of an object, see
+// Object.getOwnPropertyDescriptor.
+type PropertyDescriptor struct {
+	Writable     bool
+	Enumerable   bool
+	Configurable bool
+
+	// Accessor is true for properties defined with a getter and/or a
+	// setter, Writable is always false for them.
+	Accessor  bool
+	HasGetter bool
+	HasSetter bool
+}
+
+// DefineAccessor defines the property key of the object at objIndex as an
+// accessor whose getter and setter are Go functions; either of them may be
+// nil. The getter is called with the object as `this` and must push the
+// property value and return 1. The setter gets the new value at index 0.
+//
+// flags is a combination of DefPropEnumerable, DefPropConfigurable and
+// DefPropForce; the attributes not included are cleared.
+func (d *Context) DefineAccessor(objIndex int, key string, getter, setter func(*Context) int, flags uint) {
+	objIndex = d.NormalizeIndex(objIndex)
+	flags &^= DefPropWritable | DefPropHaveWritable
+	flags |= DefPropHaveEnumerable | DefPropHaveConfigurable
+
+	d.PushString(key)
+	if getter != nil {
+		d.PushGoFunction(getter)
+		flags |= DefPropHaveGetter
+	}
+	if setter != nil {
+		d.PushGoFunction(setter)
+		flags |= DefPropHaveSetter
+	}
+	d.DefProp(objIndex, flags)
+}
+
+// DefineValue defines the property key of the object at objIndex with the
+// value on top of the stack, and pops the value.
+//
+// flags is a combination of DefPropWritable, DefPropEnumerable,
+// DefPropConfigurable and DefPropForce; the attributes not included are
+// cleared.
+func (d *Context) DefineValue(objIndex int, key string, flags uint) {
+	objIndex = d.NormalizeIndex(objIndex)
+	flags |= DefPropHaveValue | DefPropHaveWritable | DefPropHaveEnumerable | DefPropHaveConfigurable
+
+	d.PushString(key)
+	d.Swap(-2, -1)
+	d.DefProp(objIndex, flags)
+}
+
+// GetOwnPropertyDescriptor describes the own property key of the object at
+// objIndex. The second result is false if there is no such property.
+func (d *Context) GetOwnPropertyDescriptor(objIndex int, key string) (PropertyDescriptor, bool) {
+	objIndex = d.NormalizeIndex(objIndex)
+	d.PushString(key)
+	d.GetPropDesc(objIndex, 0)
+	defer d.Pop()
+
+	if !d.IsObject(-1) {
+		return PropertyDescriptor{}, false
+	}
+
+	desc := PropertyDescriptor{
+		Writable:     d.getBooleanProp(-1, "writable"),
+		Enumerable:   d.getBooleanProp(-1, "enumerable"),
+		Configurable: d.getBooleanProp(-1, "configurable"),
+		Accessor:     d.HasPropString(-1, "get") || d.HasPropString(-1, "set"),
+	}
+	d.GetPropString(-1, "get")
+	desc.HasGetter = d.IsFunction(-1)
+	d.GetPropString(-2, "set")
+	desc.HasSetter = d.IsFunction(-1)
+	d.Pop2()
+
+	return desc, true
+}
+
+func (d *Context) getBooleanProp(objIndex int, key string) bool {
+	d.GetPropString(objIndex, key)
+	result := d.ToBoolean(-1)
+	d.Pop()
+	return result
+}
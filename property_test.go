@@ -0,0 +1,81 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestDefineAccessor(c *C) {
+	value := 1
+	s.ctx.PushObject()
+	s.ctx.DefineAccessor(-1, "value", func(ctx *Context) int {
+		ctx.PushInt(value)
+		return 1
+	}, func(ctx *Context) int {
+		value = ctx.GetInt(0)
+		return 0
+	}, DefPropEnumerable)
+	s.ctx.PutGlobalString("obj")
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+
+	err := s.ctx.PevalString(`obj.value = obj.value + 41; Object.keys(obj).join()`)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, 42)
+	c.Assert(s.ctx.GetString(-1), Equals, "value")
+	s.ctx.Pop()
+
+	s.ctx.GetGlobalString("obj")
+	desc, ok := s.ctx.GetOwnPropertyDescriptor(-1, "value")
+	c.Assert(ok, Equals, true)
+	c.Assert(desc, Equals, PropertyDescriptor{
+		Enumerable: true,
+		Accessor:   true,
+		HasGetter:  true,
+		HasSetter:  true,
+	})
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestDefineAccessor_ReadOnly(c *C) {
+	s.ctx.PushObject()
+	s.ctx.DefineAccessor(-1, "answer", func(ctx *Context) int {
+		ctx.PushInt(42)
+		return 1
+	}, nil, 0)
+	s.ctx.PutGlobalString("obj")
+
+	err := s.ctx.PevalString(`"use strict"; obj.answer = 1`)
+	c.Assert(err, NotNil)
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	s.ctx.Pop()
+
+	s.ctx.PevalString(`obj.answer`)
+	c.Assert(s.ctx.GetInt(-1), Equals, 42)
+}
+
+func (s *DuktapeSuite) TestDefineValue(c *C) {
+	s.ctx.PushObject()
+	s.ctx.PushString("fixed")
+	s.ctx.DefineValue(-2, "name", DefPropEnumerable)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+
+	desc, ok := s.ctx.GetOwnPropertyDescriptor(-1, "name")
+	c.Assert(ok, Equals, true)
+	c.Assert(desc, Equals, PropertyDescriptor{Enumerable: true})
+
+	s.ctx.DupTop()
+	s.ctx.PutGlobalString("obj")
+	err := s.ctx.PevalString(`obj.name = "changed"; obj.name`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "fixed")
+	s.ctx.Pop()
+
+	s.ctx.PushInt(1)
+	s.ctx.DefineValue(-2, "counter", DefPropWritable|DefPropConfigurable)
+	desc, _ = s.ctx.GetOwnPropertyDescriptor(-1, "counter")
+	c.Assert(desc, Equals, PropertyDescriptor{Writable: true, Configurable: true})
+}
+
+func (s *DuktapeSuite) TestGetOwnPropertyDescriptor_Missing(c *C) {
+	s.ctx.PevalString(`({})`)
+	_, ok := s.ctx.GetOwnPropertyDescriptor(-1, "toString")
+	c.Assert(ok, Equals, false)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
@@ -0,0 +1,147 @@
+package duktape
+
+// ProxyHandler is the Go side of a Proxy created with PushProxy. Every trap
+// is optional: a handler implements any of ProxyGetTrap, ProxySetTrap,
+// ProxyHasTrap, ProxyDeletePropertyTrap, ProxyOwnKeysTrap, ProxyApplyTrap
+// and ProxyConstructTrap, and the operations without a trap go straight to
+// the target.
+//
+// Trap methods are called like Go functions: the trap arguments are on the
+// stack starting with the target at index 0. Property traps only see string
+// keys, operations on symbol keys always go to the target.
+type ProxyHandler interface{}
+
+// ProxyGetTrap intercepts property reads. The key is at index 1 and the
+// receiver at index 2. Get must push the property value and return 1, or
+// return 0 to read the property of the target instead, so inherited methods
+// like toString keep working.
+type ProxyGetTrap interface {
+	Get(c *Context, key string) int
+}
+
+// ProxySetTrap intercepts property writes. The key is at index 1, the new
+// value at index 2 and the receiver at index 3. Returning false rejects the
+// write, which throws a TypeError in strict code.
+type ProxySetTrap interface {
+	Set(c *Context, key string) bool
+}
+
+// ProxyHasTrap intercepts the `in` operator.
+type ProxyHasTrap interface {
+	Has(c *Context, key string) bool
+}
+
+// ProxyDeletePropertyTrap intercepts the `delete` operator. Returning false
+// rejects the deletion, which throws a TypeError in strict code.
+type ProxyDeletePropertyTrap interface {
+	DeleteProperty(c *Context, key string) bool
+}
+
+// ProxyOwnKeysTrap intercepts key enumeration. Object.getOwnPropertyNames()
+// and Reflect.ownKeys() list the keys OwnKeys returns. Duktape has no
+// getOwnPropertyDescriptor trap and checks enumerability on the target, so
+// Object.keys() and for-in loops only list the returned keys which are
+// enumerable properties of the target.
+type ProxyOwnKeysTrap interface {
+	OwnKeys(c *Context) []string
+}
+
+// ProxyApplyTrap intercepts calls of a callable target. `this` is at index
+// 1 and the arguments array at index 2. Apply returns like a Go function.
+type ProxyApplyTrap interface {
+	Apply(c *Context) int
+}
+
+// ProxyConstructTrap intercepts `new` of a constructable target. The
+// arguments array is at index 1 and new.target at index 2. Construct must
+// push an object and return 1.
+type ProxyConstructTrap interface {
+	Construct(c *Context) int
+}
+
+// PushProxy pushes a new Proxy object for the target object at targetIndex
+// whose traps are implemented by handler, and returns the non-negative
+// index (relative to stack bottom) of the proxy.
+//
+// See: https://github.com/svaarala/duktape/blob/master/doc/es6-proxy.rst
+func (d *Context) PushProxy(targetIndex int, handler ProxyHandler) int {
+	d.Dup(targetIndex)
+	d.PushObject()
+
+	if h, ok := handler.(ProxyGetTrap); ok {
+		d.PushGoFunction(func(c *Context) int {
+			if !c.IsSymbol(1) && h.Get(c, c.GetString(1)) > 0 {
+				return 1
+			}
+			c.Dup(1)
+			c.GetProp(0)
+			return 1
+		})
+		d.PutPropString(-2, "get")
+	}
+
+	if h, ok := handler.(ProxySetTrap); ok {
+		d.PushGoFunction(func(c *Context) int {
+			if c.IsSymbol(1) {
+				c.Dup(1)
+				c.Dup(2)
+				c.PushBoolean(c.PutProp(0))
+				return 1
+			}
+			c.PushBoolean(h.Set(c, c.GetString(1)))
+			return 1
+		})
+		d.PutPropString(-2, "set")
+	}
+
+	if h, ok := handler.(ProxyHasTrap); ok {
+		d.PushGoFunction(func(c *Context) int {
+			if c.IsSymbol(1) {
+				c.Dup(1)
+				c.PushBoolean(c.HasProp(0))
+				return 1
+			}
+			c.PushBoolean(h.Has(c, c.GetString(1)))
+			return 1
+		})
+		d.PutPropString(-2, "has")
+	}
+
+	if h, ok := handler.(ProxyDeletePropertyTrap); ok {
+		d.PushGoFunction(func(c *Context) int {
+			if c.IsSymbol(1) {
+				c.Dup(1)
+				c.PushBoolean(c.DelProp(0))
+				return 1
+			}
+			c.PushBoolean(h.DeleteProperty(c, c.GetString(1)))
+			return 1
+		})
+		d.PutPropString(-2, "deleteProperty")
+	}
+
+	if h, ok := handler.(ProxyOwnKeysTrap); ok {
+		d.PushGoFunction(func(c *Context) int {
+			keys := h.OwnKeys(c)
+			c.PushArray()
+			for i, key := range keys {
+				c.PushString(key)
+				c.PutPropIndex(-2, uint(i))
+			}
+			return 1
+		})
+		d.PutPropString(-2, "ownKeys")
+	}
+
+	if h, ok := handler.(ProxyApplyTrap); ok {
+		d.PushGoFunction(h.Apply)
+		d.PutPropString(-2, "apply")
+	}
+
+	if h, ok := handler.(ProxyConstructTrap); ok {
+		d.PushGoFunction(h.Construct)
+		d.PutPropString(-2, "construct")
+	}
+
+	return d.PushProxyObject(0)
+}
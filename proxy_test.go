@@ -0,0 +1,134 @@
+package duktape
+
+import (
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+type configProxy struct {
+	values map[string]string
+	loads  int
+}
+
+func (p *configProxy) Get(c *Context, key string) int {
+	v, ok := p.values[key]
+	if !ok {
+		return 0
+	}
+	p.loads++
+	c.PushString(v)
+	return 1
+}
+
+func (p *configProxy) Set(c *Context, key string) bool {
+	if key == "readonly" {
+		return false
+	}
+	p.values[key] = c.SafeToString(2)
+	return true
+}
+
+func (p *configProxy) Has(c *Context, key string) bool {
+	_, ok := p.values[key]
+	return ok
+}
+
+func (p *configProxy) DeleteProperty(c *Context, key string) bool {
+	delete(p.values, key)
+	return true
+}
+
+func (p *configProxy) OwnKeys(c *Context) []string {
+	var keys []string
+	for k := range p.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *DuktapeSuite) TestPushProxy(c *C) {
+	handler := &configProxy{values: map[string]string{"host": "localhost", "port": "8080"}}
+
+	s.ctx.PushObject()
+	idx := s.ctx.PushProxy(-1, handler)
+	c.Assert(idx, Equals, 1)
+	s.ctx.PutGlobalString("config")
+	s.ctx.Pop()
+
+	err := s.ctx.PevalString(`config.host + ":" + config.port`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "localhost:8080")
+	c.Assert(handler.loads, Equals, 2)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`config.user = "duk"; delete config.port; ["user" in config, "port" in config, config.missing]`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `[true,false,null]`)
+	c.Assert(handler.values, DeepEquals, map[string]string{"host": "localhost", "user": "duk"})
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`Object.getOwnPropertyNames(config).join()`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "host,user")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`"use strict"; config.readonly = 1`)
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`String(config)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "[object Object]")
+}
+
+func (s *DuktapeSuite) TestPushProxy_OwnKeysTarget(c *C) {
+	handler := &configProxy{values: map[string]string{"host": "localhost", "port": "8080"}}
+
+	err := s.ctx.PevalString(`({port: "80", local: true})`)
+	c.Assert(err, IsNil)
+	s.ctx.PushProxy(-1, handler)
+	s.ctx.PutGlobalString("config")
+	s.ctx.PutGlobalString("target")
+
+	err = s.ctx.PevalString(`[Object.getOwnPropertyNames(config).join(), Object.keys(config).join(), Object.keys(target).join()]`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `["host,port","port","port,local"]`)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`Object.freeze({})`)
+	c.Assert(err, IsNil)
+	s.ctx.PushProxy(-1, handler)
+	s.ctx.PutGlobalString("frozenConfig")
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`[Object.getOwnPropertyNames(frozenConfig).join(), Object.keys(frozenConfig).length]`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `["host,port",0]`)
+}
+
+type callProxy struct{}
+
+func (callProxy) Apply(c *Context) int {
+	c.GetPropIndex(2, 0)
+	c.PushNumber(c.GetNumber(-1) * 2)
+	return 1
+}
+
+func (callProxy) Construct(c *Context) int {
+	c.PushObject()
+	c.GetPropIndex(1, 0)
+	c.PutPropString(-2, "value")
+	return 1
+}
+
+func (s *DuktapeSuite) TestPushProxy_Callable(c *C) {
+	s.ctx.PevalString(`(function () {})`)
+	s.ctx.PushProxy(-1, callProxy{})
+	s.ctx.PutGlobalString("fn")
+
+	err := s.ctx.PevalString(`fn(21) + new fn("x").value`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "42x")
+}
@@ -0,0 +1,66 @@
+#include <stdint.h>
+#include <stdlib.h>
+#include "duktape.h"
+#include "heapdata.h"
+#include "_cgo_export.h"
+
+/*
+ *  Math.random() provider, see DUK_USE_GET_RANDOM_DOUBLE in duk_config.h.
+ *  When a Go random source is registered for the heap the value is taken
+ *  from Go, otherwise a per-heap xoroshiro128+ (the same algorithm Duktape
+ *  uses internally) is used so that the default path never leaves C.
+ */
+
+static uint64_t duk__go_splitmix64(uint64_t *x) {
+	uint64_t z;
+	z = (*x += 0x9E3779B97F4A7C15ULL);
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9ULL;
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EBULL;
+	return z ^ (z >> 31);
+}
+
+static uint64_t duk__go_rotl(const uint64_t x, int k) {
+	return (x << k) | (x >> (64 - k));
+}
+
+static uint64_t duk__go_xoroshiro128plus(uint64_t *s) {
+	uint64_t s0 = s[0];
+	uint64_t s1 = s[1];
+	uint64_t res = s0 + s1;
+
+	s1 ^= s0;
+	s[0] = duk__go_rotl(s0, 55) ^ s1 ^ (s1 << 14);
+	s[1] = duk__go_rotl(s1, 36);
+
+	return res;
+}
+
+void duk_go_random_seed(duk_go_heapdata *h, uint64_t seed) {
+	int i;
+
+	for (i = 0; i < 64; i++) {
+		h->random_state[i & 0x01] = duk__go_splitmix64(&seed);
+	}
+}
+
+double duk_go_random_double(void *thr, void *udata) {
+	duk_go_heapdata *h = (duk_go_heapdata *) udata;
+	double v;
+
+	if (h == NULL) {
+		return 0.0;
+	}
+	if (h->go_random) {
+		v = goRandomDouble((duk_context *) thr, udata);
+		if (v == DUK_GO_RANDOM_THROW) {
+			/* Thrown once Go has returned. */
+			(void) duk_throw((duk_context *) thr);
+		}
+		if (v >= 0.0 && v < 1.0) {
+			return v;
+		}
+	}
+
+	/* Top 53 bits give a uniformly distributed double in [0,1). */
+	return (double) (duk__go_xoroshiro128plus(h->random_state) >> 11) * (1.0 / 9007199254740992.0);
+}
@@ -0,0 +1,31 @@
+package duktape
+
+/*
+#include "duktape.h"
+#include "heapdata.h"
+*/
+import "C"
+import "unsafe"
+
+// goRandomDouble returns the next value of the random source of the heap,
+// or -1 for the internal generator. A panic of the source is recovered and
+// its Error pushed to be thrown by random.c, a panic must not unwind through
+// the C frames of Duktape.
+//
+//export goRandomDouble
+func goRandomDouble(cCtx *C.duk_context, udata unsafe.Pointer) (v C.double) {
+	ctx := heapData.get(udata)
+	if ctx == nil || ctx.random == nil {
+		return -1
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.recordPanic(r)
+			contextFromPointer(cCtx).pushPanicValue(r)
+			v = C.DUK_GO_RANDOM_THROW
+		}
+	}()
+
+	return C.double(ctx.random())
+}
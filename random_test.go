@@ -0,0 +1,55 @@
+package duktape
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestMathRandom(c *C) {
+	err := s.ctx.PevalString(`
+		var ok = true;
+		for (var i = 0; i < 1000; i++) {
+			var r = Math.random();
+			ok = ok && r >= 0 && r < 1;
+		}
+		ok;
+	`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetBoolean(-1), Equals, true)
+}
+
+func (s *DuktapeSuite) TestMathRandomSeeded(c *C) {
+	run := func(seed int64) []float64 {
+		ctx := NewWithFlags(&Flags{Random: rand.New(rand.NewSource(seed)).Float64})
+		defer ctx.DestroyHeap()
+
+		err := ctx.PevalString(`[Math.random(), Math.random(), Math.random()]`)
+		c.Assert(err, IsNil)
+
+		var result []float64
+		for i := uint(0); i < 3; i++ {
+			ctx.GetPropIndex(-1, i)
+			result = append(result, ctx.GetNumber(-1))
+			ctx.Pop()
+		}
+		return result
+	}
+
+	expected := rand.New(rand.NewSource(42))
+	result := run(42)
+	for _, v := range result {
+		c.Assert(v, Equals, expected.Float64())
+	}
+	c.Assert(run(42), DeepEquals, result)
+	c.Assert(run(7), Not(DeepEquals), result)
+}
+
+func (s *DuktapeSuite) TestMathRandomOutOfRange(c *C) {
+	ctx := NewWithFlags(&Flags{Random: func() float64 { return 1 }})
+	defer ctx.DestroyHeap()
+
+	err := ctx.PevalString(`Math.random()`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetNumber(-1) < 1, Equals, true)
+}
@@ -0,0 +1,142 @@
+package duktape
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PutFunctions stores every Go function of fns as a property of the object
+// at objIndex, keyed by its name in the map. It is the Go counterpart of
+// duk_put_function_list: all the functions are created and stored in a
+// single call into C, so registering a large API stays cheap. The names are
+// converted like the keys of PutPropLstring. Nothing is stored if one of
+// the functions is nil.
+//
+// See: http://duktape.org/api.html#duk_put_function_list
+func (d *Context) PutFunctions(objIndex int, fns map[string]func(*Context) int) error {
+	if err := checkFunctions(fns); err != nil {
+		return err
+	}
+	names := sortedFunctionNames(fns)
+	list := make([]func(*Context) int, len(names))
+	for i, name := range names {
+		list[i] = fns[name]
+	}
+	d.putGoFunctions(objIndex, names, list)
+	return nil
+}
+
+// PutConstants stores every value of consts as a property of the object at
+// objIndex, keyed by its name in the map and converted like the keys of
+// PutPropLstring. Numbers are stored in a single call into C like
+// duk_put_number_list does, other values are converted with PushGoValue.
+// All the values are converted before any is stored, so when one can not
+// be converted its error is returned and the object is left unchanged.
+//
+// See: http://duktape.org/api.html#duk_put_number_list
+func (d *Context) PutConstants(objIndex int, consts map[string]interface{}) error {
+	objIndex = d.NormalizeIndex(objIndex)
+	top := d.GetTop()
+	defer d.SetTop(top)
+
+	names := make([]string, 0, len(consts))
+	for name := range consts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var numNames, valNames []string
+	var numValues []float64
+	for _, name := range names {
+		v := consts[name]
+		if n, ok := numberValue(v); ok {
+			numNames = append(numNames, name)
+			numValues = append(numValues, n)
+			continue
+		}
+		if err := d.PushGoValue(v); err != nil {
+			return fmt.Errorf("constant %q: %s", name, err)
+		}
+		valNames = append(valNames, name)
+	}
+
+	d.putNumbers(objIndex, numNames, numValues)
+	for i, name := range valNames {
+		d.Dup(top + i)
+		d.PutPropLstring(objIndex, name)
+	}
+	return nil
+}
+
+// RegisterModule registers fns on the global object under the dotted
+// namespace path, e.g. "app.db" makes them callable as app.db.query().
+// Missing objects along the path are created and existing ones are
+// extended, so several calls may share a namespace. An error is returned,
+// before anything is registered, for malformed paths and nil functions, and
+// when a part of the path holds a non-object value.
+func (d *Context) RegisterModule(path string, fns map[string]func(*Context) int) error {
+	parts := strings.Split(path, ".")
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("malformed module path %q", path)
+		}
+	}
+	if err := checkFunctions(fns); err != nil {
+		return err
+	}
+
+	top := d.GetTop()
+	defer d.SetTop(top)
+
+	d.PushGlobalObject()
+	for i, part := range parts {
+		if !d.GetPropString(-1, part) {
+			d.Pop()
+			d.PushObject()
+			d.Dup(-1)
+			d.PutPropString(-3, part)
+		} else if !d.IsObject(-1) {
+			return fmt.Errorf("cannot register module %q: %s is not an object",
+				path, strings.Join(parts[:i+1], "."))
+		}
+		d.Remove(-2)
+	}
+
+	return d.PutFunctions(-1, fns)
+}
+
+// checkFunctions returns an error for the first nil function of fns.
+func checkFunctions(fns map[string]func(*Context) int) error {
+	for _, name := range sortedFunctionNames(fns) {
+		if fns[name] == nil {
+			return fmt.Errorf("function %q is nil", name)
+		}
+	}
+	return nil
+}
+
+func sortedFunctionNames(fns map[string]func(*Context) int) []string {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// numberValue returns v as a float64 if it holds a Go number.
+func numberValue(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
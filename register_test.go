@@ -0,0 +1,106 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestPutFunctions(c *C) {
+	s.ctx.PushObject()
+	err := s.ctx.PutFunctions(-1, map[string]func(*Context) int{
+		"add": func(ctx *Context) int {
+			ctx.PushNumber(ctx.GetNumber(0) + ctx.GetNumber(1))
+			return 1
+		},
+		"neg": func(ctx *Context) int {
+			ctx.PushNumber(-ctx.GetNumber(0))
+			return 1
+		},
+	})
+	c.Assert(err, IsNil)
+	s.ctx.PutGlobalString("math2")
+
+	err = s.ctx.PevalString(`math2.neg(math2.add(2, 3)) + ':' + Object.keys(math2).join()`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "-5:add,neg")
+	s.ctx.Pop()
+
+	s.ctx.PushObject()
+	err = s.ctx.PutFunctions(-1, map[string]func(*Context) int{"f": nil})
+	c.Assert(err, ErrorMatches, `function "f" is nil`)
+	c.Assert(s.ctx.GetPropString(-1, "f"), Equals, false)
+	s.ctx.Pop2()
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
+
+func (s *DuktapeSuite) TestPutFunctionsKeys(c *C) {
+	ctx := NewWithFlags(&Flags{NormalizeUTF8: true})
+	defer ctx.DestroyHeap()
+
+	f := func(ctx *Context) int { return 0 }
+	ctx.PushObject()
+	err := ctx.PutFunctions(-1, map[string]func(*Context) int{"a\x00b": f, "😀": f})
+	c.Assert(err, IsNil)
+	err = ctx.PutConstants(-1, map[string]interface{}{"c\x00d": 1, "n😀": 2, "s😀": "x"})
+	c.Assert(err, IsNil)
+	ctx.PutGlobalString("o")
+
+	err = ctx.PevalString(`Object.keys(o).map(function(k) { return k.length; }).join() +
+		':' + typeof o['😀'] + ':' + o['n😀'] + ':' + o['s😀']`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetString(-1), Equals, "3,2,3,3,3:function:2:x")
+}
+
+func (s *DuktapeSuite) TestPutConstants(c *C) {
+	s.ctx.PushObject()
+	err := s.ctx.PutConstants(-1, map[string]interface{}{
+		"MAX":     uint8(255),
+		"PI":      3.5,
+		"NAME":    "app",
+		"DEBUG":   true,
+		"LEVELS":  []string{"info", "warn"},
+		"NOTHING": nil,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+	s.ctx.PutGlobalString("consts")
+
+	err = s.ctx.PevalString(`[consts.MAX, consts.PI, consts.NAME, consts.DEBUG, consts.LEVELS[1], consts.NOTHING].join()`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "255,3.5,app,true,warn,")
+	s.ctx.Pop()
+
+	s.ctx.PushObject()
+	err = s.ctx.PutConstants(-1, map[string]interface{}{
+		"A":   1,
+		"BAD": map[int]int{1: 1},
+		"C":   "c",
+	})
+	c.Assert(err, ErrorMatches, `constant "BAD": cannot push map with int keys`)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, "{}")
+}
+
+func (s *DuktapeSuite) TestRegisterModule(c *C) {
+	query := func(ctx *Context) int {
+		ctx.PushString("rows:" + ctx.SafeToString(0))
+		return 1
+	}
+	err := s.ctx.RegisterModule("app.db", map[string]func(*Context) int{"query": query})
+	c.Assert(err, IsNil)
+	err = s.ctx.RegisterModule("app.log", map[string]func(*Context) int{"info": query})
+	c.Assert(err, IsNil)
+	err = s.ctx.RegisterModule("app.db", map[string]func(*Context) int{"exec": query})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+
+	err = s.ctx.PevalString(`app.db.query(1) + ',' + app.db.exec(2) + ',' + Object.keys(app).join()`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "rows:1,rows:2,db,log")
+	s.ctx.Pop()
+
+	s.ctx.PushNumber(1)
+	s.ctx.PutGlobalString("num")
+	err = s.ctx.RegisterModule("num.x", map[string]func(*Context) int{"f": query})
+	c.Assert(err, ErrorMatches, `cannot register module "num.x": num is not an object`)
+	err = s.ctx.RegisterModule("app..db", nil)
+	c.Assert(err, ErrorMatches, `malformed module path "app..db"`)
+	c.Assert(s.ctx.GetTop(), Equals, 0)
+}
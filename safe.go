@@ -0,0 +1,393 @@
+package duktape
+
+/*
+#include <stdlib.h>
+#include "duktape.h"
+
+enum {
+	SAFE_CALL,
+	SAFE_CALL_METHOD,
+	SAFE_CALL_PROP,
+	SAFE_NEW,
+	SAFE_THROW,
+	SAFE_ERROR,
+	SAFE_REQUIRE_BOOLEAN,
+	SAFE_REQUIRE_BUFFER,
+	SAFE_REQUIRE_CONSTRUCTABLE,
+	SAFE_REQUIRE_CONTEXT,
+	SAFE_REQUIRE_FUNCTION,
+	SAFE_REQUIRE_HEAPPTR,
+	SAFE_REQUIRE_NULL,
+	SAFE_REQUIRE_NUMBER,
+	SAFE_REQUIRE_OBJECT,
+	SAFE_REQUIRE_OBJECT_COERCIBLE,
+	SAFE_REQUIRE_POINTER,
+	SAFE_REQUIRE_STRING,
+	SAFE_REQUIRE_TYPE_MASK,
+	SAFE_REQUIRE_UNDEFINED
+};
+
+typedef struct {
+	int op;
+	duk_idx_t nargs;
+	duk_idx_t obj_idx;
+	duk_errcode_t err_code;
+	const char *msg;
+	duk_uint_t mask;
+} _duk_safe_args;
+
+// _duk_safe_op runs inside duk_safe_call: whatever it throws is caught
+// there, before reaching any Go frame. The safe call shares the stack of
+// the caller, the value to check is the one on top.
+static duk_ret_t _duk_safe_op(duk_context *ctx, void *udata) {
+	_duk_safe_args *args = (_duk_safe_args *) udata;
+
+	switch (args->op) {
+	case SAFE_CALL:
+		duk_call(ctx, args->nargs);
+		break;
+	case SAFE_CALL_METHOD:
+		duk_call_method(ctx, args->nargs);
+		break;
+	case SAFE_CALL_PROP:
+		duk_call_prop(ctx, args->obj_idx, args->nargs);
+		break;
+	case SAFE_NEW:
+		duk_new(ctx, args->nargs);
+		break;
+	case SAFE_THROW:
+		return duk_throw(ctx);
+	case SAFE_ERROR:
+		return duk_error(ctx, args->err_code, "%s", args->msg);
+	case SAFE_REQUIRE_BOOLEAN:
+		duk_require_boolean(ctx, -1);
+		break;
+	case SAFE_REQUIRE_BUFFER:
+		duk_require_buffer(ctx, -1, NULL);
+		break;
+	case SAFE_REQUIRE_CONSTRUCTABLE:
+		duk_require_constructable(ctx, -1);
+		break;
+	case SAFE_REQUIRE_CONTEXT:
+		duk_require_context(ctx, -1);
+		break;
+	case SAFE_REQUIRE_FUNCTION:
+		duk_require_function(ctx, -1);
+		break;
+	case SAFE_REQUIRE_HEAPPTR:
+		duk_require_heapptr(ctx, -1);
+		break;
+	case SAFE_REQUIRE_NULL:
+		duk_require_null(ctx, -1);
+		break;
+	case SAFE_REQUIRE_NUMBER:
+		duk_require_number(ctx, -1);
+		break;
+	case SAFE_REQUIRE_OBJECT:
+		duk_require_object(ctx, -1);
+		break;
+	case SAFE_REQUIRE_OBJECT_COERCIBLE:
+		duk_require_object_coercible(ctx, -1);
+		break;
+	case SAFE_REQUIRE_POINTER:
+		duk_require_pointer(ctx, -1);
+		break;
+	case SAFE_REQUIRE_STRING:
+		duk_require_lstring(ctx, -1, NULL);
+		break;
+	case SAFE_REQUIRE_TYPE_MASK:
+		duk_require_type_mask(ctx, -1, args->mask);
+		break;
+	case SAFE_REQUIRE_UNDEFINED:
+		duk_require_undefined(ctx, -1);
+		break;
+	}
+	return 1;
+}
+
+static duk_int_t _duk_safe_call(duk_context *ctx, _duk_safe_args *args, duk_idx_t nargs) {
+	return duk_safe_call(ctx, _duk_safe_op, args, nargs, 1);
+}
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// SafeContext provides checked variants of the calls of Context which throw
+// when they fail. A throw is a longjmp in C, and it is undefined behaviour
+// when it jumps over Go frames: it typically crashes the program. The
+// variants run the call inside duk_safe_call, so whatever is thrown is caught
+// before control returns to Go and comes back as an *Error.
+//
+// The calls of the embedded Context which are not shadowed by SafeContext
+// are the plain ones.
+type SafeContext struct {
+	*Context
+}
+
+// Safe returns the checked variants of the throwing calls of the context.
+func (d *Context) Safe() SafeContext {
+	return SafeContext{d}
+}
+
+// safeCall runs the operation of args on the nargs values on top of the
+// stack. The values are replaced by the result, or by the error which is
+// returned.
+func (s SafeContext) safeCall(args C._duk_safe_args, nargs int) error {
+	if args.nargs < 0 || s.GetTop() < nargs {
+		return argsError(ErrType, "invalid args")
+	}
+	result := int(C._duk_safe_call(s.duk_context, &args, C.duk_idx_t(nargs)))
+	return s.castStringToError(result)
+}
+
+// require checks the value at index with op. The stack is left unchanged.
+func (s SafeContext) require(op C.int, index int, mask uint) error {
+	if !s.IsValidIndex(index) {
+		return argsError(ErrRange, "invalid stack index %d", index)
+	}
+	if !s.CheckStack(1) {
+		return argsError(ErrRange, "valstack limit")
+	}
+	s.Dup(index)
+	err := s.safeCall(C._duk_safe_args{op: op, mask: C.duk_uint_t(mask)}, 1)
+	s.Pop()
+	return err
+}
+
+// argsError returns the error for the invalid arguments of a call, the way
+// Duktape would have thrown it. It is made in Go: the stack may be full.
+func argsError(code ErrorCode, format string, a ...interface{}) error {
+	return &Error{Code: code, Type: code.String(), Message: fmt.Sprintf(format, a...)}
+}
+
+// Call is the checked variant of Context.Call. On failure the function and
+// the arguments are replaced by the error, like Pcall does.
+func (s SafeContext) Call(nargs int) error {
+	return s.safeCall(C._duk_safe_args{op: C.SAFE_CALL, nargs: C.duk_idx_t(nargs)}, nargs+1)
+}
+
+// CallMethod is the checked variant of Context.CallMethod. On failure the
+// function, this binding and the arguments are replaced by the error.
+func (s SafeContext) CallMethod(nargs int) error {
+	return s.safeCall(C._duk_safe_args{op: C.SAFE_CALL_METHOD, nargs: C.duk_idx_t(nargs)}, nargs+2)
+}
+
+// CallProp is the checked variant of Context.CallProp. On failure the key
+// and the arguments are replaced by the error.
+func (s SafeContext) CallProp(objIndex int, nargs int) error {
+	if !s.IsValidIndex(objIndex) {
+		return argsError(ErrRange, "invalid stack index %d", objIndex)
+	}
+	return s.safeCall(C._duk_safe_args{
+		op:      C.SAFE_CALL_PROP,
+		nargs:   C.duk_idx_t(nargs),
+		obj_idx: C.duk_idx_t(s.NormalizeIndex(objIndex)),
+	}, nargs+1)
+}
+
+// New is the checked variant of Context.New. On failure the constructor and
+// the arguments are replaced by the error.
+func (s SafeContext) New(nargs int) error {
+	return s.safeCall(C._duk_safe_args{op: C.SAFE_NEW, nargs: C.duk_idx_t(nargs)}, nargs+1)
+}
+
+// Throw returns the value on top of the stack as an error instead of
+// throwing it, and pops it.
+func (s SafeContext) Throw() error {
+	if s.GetTop() == 0 {
+		return argsError(ErrType, "invalid args")
+	}
+	err := s.safeCall(C._duk_safe_args{op: C.SAFE_THROW}, 1)
+	s.Pop()
+	return err
+}
+
+// Error returns the error Context.Error would throw.
+func (s SafeContext) Error(errCode int, str string) error {
+	if !s.CheckStack(1) {
+		return argsError(ErrRange, "valstack limit")
+	}
+	__str__ := C.CString(str)
+	err := s.safeCall(C._duk_safe_args{op: C.SAFE_ERROR, err_code: C.duk_errcode_t(errCode), msg: __str__}, 0)
+	C.free(unsafe.Pointer(__str__))
+	s.Pop()
+	return err
+}
+
+// Errorf returns the error Context.Errorf would throw.
+func (s SafeContext) Errorf(errCode int, format string, a ...interface{}) error {
+	return s.Error(errCode, fmt.Sprintf(format, a...))
+}
+
+// RequireBoolean is the checked variant of Context.RequireBoolean.
+func (s SafeContext) RequireBoolean(index int) (bool, error) {
+	if err := s.require(C.SAFE_REQUIRE_BOOLEAN, index, 0); err != nil {
+		return false, err
+	}
+	return s.GetBoolean(index), nil
+}
+
+// RequireBuffer is the checked variant of Context.RequireBuffer.
+func (s SafeContext) RequireBuffer(index int) (rawPtr unsafe.Pointer, outSize uint, err error) {
+	if err := s.require(C.SAFE_REQUIRE_BUFFER, index, 0); err != nil {
+		return nil, 0, err
+	}
+	rawPtr, outSize = s.GetBuffer(index)
+	return rawPtr, outSize, nil
+}
+
+// RequireCallable is the checked variant of Context.RequireCallable.
+func (s SafeContext) RequireCallable(index int) error {
+	return s.require(C.SAFE_REQUIRE_FUNCTION, index, 0)
+}
+
+// RequireConstructable is the checked variant of
+// Context.RequireConstructable.
+func (s SafeContext) RequireConstructable(index int) error {
+	return s.require(C.SAFE_REQUIRE_CONSTRUCTABLE, index, 0)
+}
+
+// RequireConstructorCall is the checked variant of
+// Context.RequireConstructorCall.
+func (s SafeContext) RequireConstructorCall() error {
+	if !s.IsConstructorCall() {
+		return argsError(ErrType, "constructor requires 'new'")
+	}
+	return nil
+}
+
+// RequireContext is the checked variant of Context.RequireContext.
+func (s SafeContext) RequireContext(index int) (*Context, error) {
+	if err := s.require(C.SAFE_REQUIRE_CONTEXT, index, 0); err != nil {
+		return nil, err
+	}
+	return s.GetContext(index), nil
+}
+
+// RequireFunction is the checked variant of Context.RequireFunction.
+func (s SafeContext) RequireFunction(index int) error {
+	return s.require(C.SAFE_REQUIRE_FUNCTION, index, 0)
+}
+
+// RequireHeapptr is the checked variant of Context.RequireHeapptr.
+func (s SafeContext) RequireHeapptr(index int) (unsafe.Pointer, error) {
+	if err := s.require(C.SAFE_REQUIRE_HEAPPTR, index, 0); err != nil {
+		return nil, err
+	}
+	return s.GetHeapptr(index), nil
+}
+
+// RequireInt is the checked variant of Context.RequireInt.
+func (s SafeContext) RequireInt(index int) (int, error) {
+	if err := s.require(C.SAFE_REQUIRE_NUMBER, index, 0); err != nil {
+		return 0, err
+	}
+	return s.GetInt(index), nil
+}
+
+// RequireLstring is the checked variant of Context.RequireLstring.
+func (s SafeContext) RequireLstring(index int) (string, error) {
+	return s.RequireString(index)
+}
+
+// RequireNormalizeIndex is the checked variant of
+// Context.RequireNormalizeIndex.
+func (s SafeContext) RequireNormalizeIndex(index int) (int, error) {
+	if err := s.RequireValidIndex(index); err != nil {
+		return 0, err
+	}
+	return s.NormalizeIndex(index), nil
+}
+
+// RequireNull is the checked variant of Context.RequireNull.
+func (s SafeContext) RequireNull(index int) error {
+	return s.require(C.SAFE_REQUIRE_NULL, index, 0)
+}
+
+// RequireNumber is the checked variant of Context.RequireNumber.
+func (s SafeContext) RequireNumber(index int) (float64, error) {
+	if err := s.require(C.SAFE_REQUIRE_NUMBER, index, 0); err != nil {
+		return 0, err
+	}
+	return s.GetNumber(index), nil
+}
+
+// RequireObject is the checked variant of Context.RequireObject.
+func (s SafeContext) RequireObject(index int) error {
+	return s.require(C.SAFE_REQUIRE_OBJECT, index, 0)
+}
+
+// RequireObjectCoercible is the checked variant of
+// Context.RequireObjectCoercible.
+func (s SafeContext) RequireObjectCoercible(index int) error {
+	return s.require(C.SAFE_REQUIRE_OBJECT_COERCIBLE, index, 0)
+}
+
+// RequirePointer is the checked variant of Context.RequirePointer.
+func (s SafeContext) RequirePointer(index int) (unsafe.Pointer, error) {
+	if err := s.require(C.SAFE_REQUIRE_POINTER, index, 0); err != nil {
+		return nil, err
+	}
+	return s.GetPointer(index), nil
+}
+
+// RequireStack is the checked variant of Context.RequireStack.
+func (s SafeContext) RequireStack(extra int) error {
+	if !s.CheckStack(extra) {
+		return argsError(ErrRange, "valstack limit")
+	}
+	return nil
+}
+
+// RequireStackTop is the checked variant of Context.RequireStackTop.
+func (s SafeContext) RequireStackTop(top int) error {
+	if !s.CheckStackTop(top) {
+		return argsError(ErrRange, "valstack limit")
+	}
+	return nil
+}
+
+// RequireString is the checked variant of Context.RequireString.
+func (s SafeContext) RequireString(index int) (string, error) {
+	if err := s.require(C.SAFE_REQUIRE_STRING, index, 0); err != nil {
+		return "", err
+	}
+	return s.GetString(index), nil
+}
+
+// RequireTopIndex is the checked variant of Context.RequireTopIndex.
+func (s SafeContext) RequireTopIndex() (int, error) {
+	if s.GetTop() == 0 {
+		return 0, argsError(ErrRange, "invalid stack index %d", -1)
+	}
+	return s.GetTopIndex(), nil
+}
+
+// RequireTypeMask is the checked variant of Context.RequireTypeMask.
+func (s SafeContext) RequireTypeMask(index int, mask uint) error {
+	return s.require(C.SAFE_REQUIRE_TYPE_MASK, index, mask)
+}
+
+// RequireUint is the checked variant of Context.RequireUint.
+func (s SafeContext) RequireUint(index int) (uint, error) {
+	if err := s.require(C.SAFE_REQUIRE_NUMBER, index, 0); err != nil {
+		return 0, err
+	}
+	return s.GetUint(index), nil
+}
+
+// RequireUndefined is the checked variant of Context.RequireUndefined.
+func (s SafeContext) RequireUndefined(index int) error {
+	return s.require(C.SAFE_REQUIRE_UNDEFINED, index, 0)
+}
+
+// RequireValidIndex is the checked variant of Context.RequireValidIndex.
+func (s SafeContext) RequireValidIndex(index int) error {
+	if !s.IsValidIndex(index) {
+		return argsError(ErrRange, "invalid stack index %d", index)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package duktape
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestSafeContextCalls(c *C) {
+	safe := s.ctx.Safe()
+
+	err := s.ctx.PevalString(`(function(a, b) { return a + b; })`)
+	c.Assert(err, IsNil)
+	s.ctx.PushInt(1)
+	s.ctx.PushInt(2)
+	c.Assert(safe.Call(2), IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 3)
+	s.ctx.Pop()
+
+	err = s.ctx.PevalString(`({ name: 'obj', hello: function(to) { return this.name + ' says hi to ' + to; } })`)
+	c.Assert(err, IsNil)
+	s.ctx.PushString("hello")
+	s.ctx.PushString("you")
+	c.Assert(safe.CallProp(0, 1), IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "obj says hi to you")
+	s.ctx.Pop()
+
+	s.ctx.PushString("missing")
+	err = safe.CallProp(0, 0)
+	c.Assert(err, FitsTypeOf, &Error{})
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	c.Assert(s.ctx.GetTop(), Equals, 2)
+	s.ctx.Pop()
+
+	s.ctx.GetPropString(0, "hello")
+	s.ctx.Dup(0)
+	s.ctx.PushString("me")
+	c.Assert(safe.CallMethod(1), IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "obj says hi to me")
+	s.ctx.SetTop(0)
+
+	s.ctx.GetGlobalString("Date")
+	s.ctx.PushInt(0)
+	c.Assert(safe.New(1), IsNil)
+	c.Assert(s.ctx.IsObject(-1), Equals, true)
+	s.ctx.Pop()
+
+	s.ctx.PushInt(1)
+	err = safe.New(0)
+	c.Assert(err.(*Error).Type, Equals, "TypeError")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+	s.ctx.Pop()
+
+	err = safe.Call(3)
+	c.Assert(err, ErrorMatches, "TypeError: invalid args")
+	c.Assert(err.(*Error).IsTypeError(), Equals, true)
+
+	err = s.ctx.PevalString(`(function() { throw new RangeError('out of range'); })`)
+	c.Assert(err, IsNil)
+	err = safe.Call(0)
+	c.Assert(err, ErrorMatches, "RangeError: out of range")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestSafeContextThrow(c *C) {
+	safe := s.ctx.Safe()
+
+	s.ctx.PushInt(1)
+	s.ctx.PushErrorObject(ErrType, "%s", "thrown")
+	err := safe.Throw()
+	c.Assert(err, ErrorMatches, "TypeError: thrown")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+
+	err = safe.Errorf(ErrRange, "%d is too big", 42)
+	c.Assert(err, ErrorMatches, "RangeError: 42 is too big")
+	c.Assert(err.(*Error).Frames, Not(HasLen), 0)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestSafeContextRequire(c *C) {
+	safe := s.ctx.Safe()
+
+	s.ctx.PushInt(42)
+	s.ctx.PushString("str")
+	s.ctx.PushObject()
+
+	n, err := safe.RequireInt(0)
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 42)
+
+	str, err := safe.RequireString(1)
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, "str")
+
+	_, err = safe.RequireString(0)
+	c.Assert(err, ErrorMatches, "TypeError: string required.*")
+
+	c.Assert(safe.RequireObject(2), IsNil)
+	c.Assert(safe.RequireObject(0), ErrorMatches, "TypeError: object required.*")
+	c.Assert(safe.RequireCallable(2), ErrorMatches, "TypeError: .*")
+	c.Assert(safe.RequireTypeMask(1, TypeMaskString|TypeMaskNumber), IsNil)
+	c.Assert(safe.RequireTypeMask(2, TypeMaskString|TypeMaskNumber), NotNil)
+
+	c.Assert(safe.RequireValidIndex(3), ErrorMatches, "RangeError: invalid stack index 3")
+	_, err = safe.RequireNumber(-4)
+	c.Assert(err, ErrorMatches, "RangeError: invalid stack index -4")
+	c.Assert(err.(*Error).IsRangeError(), Equals, true)
+
+	index, err := safe.RequireNormalizeIndex(-1)
+	c.Assert(err, IsNil)
+	c.Assert(index, Equals, 2)
+	err = safe.RequireConstructorCall()
+	c.Assert(err, ErrorMatches, "TypeError: constructor requires 'new'")
+	c.Assert(err.(*Error).IsTypeError(), Equals, true)
+	c.Assert(err.(*Error).Code, Equals, ErrorCode(ErrType))
+	c.Assert(s.ctx.GetTop(), Equals, 3)
+}
+
+// TestSafeContextInsideGoFunction makes the failing calls from a Go function
+// called by a script. A longjmp from any of them would skip the Go frames of
+// the function and abort the program instead of returning an error.
+func (s *DuktapeSuite) TestSafeContextInsideGoFunction(c *C) {
+	var errs []error
+	s.ctx.PushGlobalGoFunction("check", func(ctx *Context) int {
+		safe := ctx.Safe()
+
+		_, err := safe.RequireString(0)
+		errs = append(errs, err)
+		errs = append(errs, safe.RequireObjectCoercible(1))
+		errs = append(errs, safe.RequireFunction(0))
+
+		ctx.Dup(0)
+		errs = append(errs, safe.Call(0))
+		ctx.Pop()
+
+		ctx.PushString("nope")
+		errs = append(errs, safe.New(0))
+		ctx.Pop()
+
+		ctx.PushString("value")
+		errs = append(errs, safe.Throw())
+		errs = append(errs, safe.Error(ErrURI, "uri"))
+		errs = append(errs, safe.RequireConstructorCall())
+
+		ctx.PushString("done")
+		return 1
+	})
+	s.ctx.Pop()
+
+	err := s.ctx.PevalString(`check(function() { throw new EvalError('from js'); }, null)`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetString(-1), Equals, "done")
+
+	c.Assert(errs, HasLen, 8)
+	c.Assert(errs[0], ErrorMatches, "TypeError: .*")
+	c.Assert(errs[1], ErrorMatches, "TypeError: .*")
+	c.Assert(errs[2], IsNil)
+	c.Assert(errs[3], ErrorMatches, "EvalError: from js")
+	c.Assert(errs[4], ErrorMatches, "TypeError: .*")
+	c.Assert(errs[5], NotNil)
+	c.Assert(errs[6], ErrorMatches, "URIError: uri")
+	c.Assert(errs[7], ErrorMatches, "TypeError: constructor requires 'new'")
+}
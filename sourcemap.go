@@ -0,0 +1,270 @@
+package duktape
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SourceMap is a parsed source map, revision 3, which maps the lines of a
+// generated file, e.g. a minified bundle, back to the original sources.
+type SourceMap struct {
+	// File is the name of the generated file, if the map has one.
+	File string
+	// Sources are the names of the original sources, with the source root
+	// of the map applied.
+	Sources []string
+	// Names are the original identifiers the mappings refer to.
+	Names []string
+
+	// lines holds the segments of each generated line, ordered by their
+	// generated column.
+	lines [][]segment
+}
+
+// segment maps the generated columns from column on to pos, or to no
+// original position if pos is nil.
+type segment struct {
+	column int
+	pos    *SourcePosition
+}
+
+// SourcePosition is a position in an original source.
+type SourcePosition struct {
+	// Source is the name of the original source.
+	Source string
+	// Line is the line in the source, starting at 1.
+	Line int
+	// Column is the column in the line, starting at 0.
+	Column int
+	// Name is the original identifier at the position, if the map has one.
+	Name string
+}
+
+// ParseSourceMap parses a source map in the revision 3 JSON format.
+// Index maps, the ones with sections, are not supported.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	var raw struct {
+		Version    int        `json:"version"`
+		File       string     `json:"file"`
+		SourceRoot string     `json:"sourceRoot"`
+		Sources    []string   `json:"sources"`
+		Names      []string   `json:"names"`
+		Mappings   string     `json:"mappings"`
+		Sections   []struct{} `json:"sections"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+	if raw.Sections != nil {
+		return nil, errors.New("source map index maps are not supported")
+	}
+
+	m := &SourceMap{File: raw.File, Names: raw.Names}
+	for _, source := range raw.Sources {
+		if raw.SourceRoot != "" && !strings.HasSuffix(raw.SourceRoot, "/") {
+			source = raw.SourceRoot + "/" + source
+		} else {
+			source = raw.SourceRoot + source
+		}
+		m.Sources = append(m.Sources, source)
+	}
+
+	if err := m.parseMappings(raw.Mappings); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseMappings decodes the mappings, lines separated by ';' holding
+// segments separated by ','. A segment is 1, 4 or 5 base64 VLQ fields:
+// the generated column, then the source, original line, original column
+// and name indexes. The columns are relative to the previous segment of
+// the line, the other fields to the previous segment of the map.
+func (m *SourceMap) parseMappings(mappings string) error {
+	var source, line, column, name int
+	for _, generated := range strings.Split(mappings, ";") {
+		var segments []segment
+		var generatedColumn int
+		for _, s := range strings.Split(generated, ",") {
+			if s == "" {
+				continue
+			}
+			fields, err := decodeVLQ(s)
+			if err != nil {
+				return err
+			}
+			switch len(fields) {
+			case 1:
+				generatedColumn += fields[0]
+				segments = append(segments, segment{column: generatedColumn})
+				continue
+			case 4, 5:
+				generatedColumn += fields[0]
+			default:
+				return fmt.Errorf("malformed source map segment %q", s)
+			}
+
+			source += fields[1]
+			line += fields[2]
+			column += fields[3]
+			if source < 0 || source >= len(m.Sources) {
+				return fmt.Errorf("source map segment %q refers to no source", s)
+			}
+			pos := &SourcePosition{Source: m.Sources[source], Line: line + 1, Column: column}
+			if len(fields) == 5 {
+				name += fields[4]
+				if name >= 0 && name < len(m.Names) {
+					pos.Name = m.Names[name]
+				}
+			}
+			segments = append(segments, segment{column: generatedColumn, pos: pos})
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].column < segments[j].column
+		})
+		m.lines = append(m.lines, segments)
+	}
+	return nil
+}
+
+const vlqChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes the base64 VLQ numbers of a segment. Each digit holds
+// five bits of the number, least significant first, and a continuation bit;
+// the lowest bit of the number is its sign.
+func decodeVLQ(s string) ([]int, error) {
+	var values []int
+	var value, shift uint
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(vlqChars, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("malformed source map segment %q", s)
+		}
+		value |= uint(digit&31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+
+		n := int(value >> 1)
+		if value&1 != 0 {
+			n = -n
+		}
+		values = append(values, n)
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("malformed source map segment %q", s)
+	}
+	return values, nil
+}
+
+// Lookup returns the original position of the generated line, starting at
+// 1, and column, starting at 0. The position is the one of the segment at
+// or before the column, or of the first segment of the line for a column
+// before it. A negative column stands for an unknown one: the line is then
+// mapped only if all its segments point at the same original line, so that
+// a line of a bundle minified to a single line is not given a wrong
+// location.
+func (m *SourceMap) Lookup(line, column int) (SourcePosition, bool) {
+	if line < 1 || line > len(m.lines) || len(m.lines[line-1]) == 0 {
+		return SourcePosition{}, false
+	}
+	segments := m.lines[line-1]
+
+	if column < 0 {
+		var first *SourcePosition
+		for _, s := range segments {
+			switch {
+			case s.pos == nil:
+			case first == nil:
+				first = s.pos
+			case s.pos.Source != first.Source || s.pos.Line != first.Line:
+				return SourcePosition{}, false
+			}
+		}
+		if first == nil {
+			return SourcePosition{}, false
+		}
+		return *first, true
+	}
+
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].column > column
+	})
+	if i > 0 {
+		i--
+	}
+	if segments[i].pos == nil {
+		return SourcePosition{}, false
+	}
+	return *segments[i].pos, true
+}
+
+// RegisterSourceMap registers the source map, in the revision 3 JSON
+// format, of the code compiled with the file name filename. The FileName,
+// LineNumber and Frames of the errors returned afterwards, and the frames
+// of Callstack, point at the original sources, looked up by the line and
+// column Duktape recorded for the instruction being executed; the Stack is
+// left as Duktape made it. A nil sourceMap removes the map of filename.
+func (d *Context) RegisterSourceMap(filename string, sourceMap []byte) error {
+	if sourceMap == nil {
+		delete(d.sourceMaps, filename)
+		return nil
+	}
+
+	m, err := ParseSourceMap(sourceMap)
+	if err != nil {
+		return err
+	}
+	if d.sourceMaps == nil {
+		d.sourceMaps = make(map[string]*SourceMap)
+	}
+	d.sourceMaps[filename] = m
+	return nil
+}
+
+// applySourceMaps rewrites the locations of err in generated files with a
+// registered source map to the original ones.
+func (d *Context) applySourceMaps(err *Error) {
+	if len(d.sourceMaps) == 0 {
+		return
+	}
+
+	if m, ok := d.sourceMaps[err.FileName]; ok {
+		// the location is the one of the innermost compiled function
+		column := -1
+		for _, frame := range err.Frames {
+			if !frame.Native && frame.File == err.FileName && frame.Line == err.LineNumber {
+				column = frame.Column
+				break
+			}
+		}
+		if pos, ok := m.Lookup(err.LineNumber, column); ok {
+			err.FileName, err.LineNumber = pos.Source, pos.Line
+		}
+	}
+	d.mapFrames(err.Frames)
+}
+
+// mapFrames rewrites the frames in generated files with a registered source
+// map to the original locations.
+func (d *Context) mapFrames(frames []Frame) {
+	if len(d.sourceMaps) == 0 {
+		return
+	}
+
+	for i, frame := range frames {
+		if m, ok := d.sourceMaps[frame.File]; ok && !frame.Native {
+			if pos, ok := m.Lookup(frame.Line, frame.Column); ok {
+				frames[i].File, frames[i].Line, frames[i].Column = pos.Source, pos.Line, pos.Column
+			}
+		}
+	}
+}
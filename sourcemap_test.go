@@ -0,0 +1,163 @@
+package duktape
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+// bundle is a "minified" bundle of src/a.js and src/b.js, mapped by
+// bundleMap: line 1 comes from line 10 of src/a.js, lines 2 and 3 from
+// lines 3 and 5 of src/b.js.
+const bundle = `function a(){throw new Error("failed")}
+function b(){a()}
+b();`
+
+const bundleMap = `{
+	"version": 3,
+	"file": "bundle.min.js",
+	"sourceRoot": "src",
+	"sources": ["a.js", "b.js"],
+	"names": ["fail"],
+	"mappings": "AASAA;ACPA;AAEA"
+}`
+
+func (s *DuktapeSuite) TestParseSourceMap(c *C) {
+	m, err := ParseSourceMap([]byte(bundleMap))
+	c.Assert(err, IsNil)
+	c.Assert(m.File, Equals, "bundle.min.js")
+	c.Assert(m.Sources, DeepEquals, []string{"src/a.js", "src/b.js"})
+
+	pos, ok := m.Lookup(1, 0)
+	c.Assert(ok, Equals, true)
+	c.Assert(pos, Equals, SourcePosition{Source: "src/a.js", Line: 10, Column: 0, Name: "fail"})
+	pos, ok = m.Lookup(3, -1)
+	c.Assert(ok, Equals, true)
+	c.Assert(pos, Equals, SourcePosition{Source: "src/b.js", Line: 5})
+	_, ok = m.Lookup(4, 0)
+	c.Assert(ok, Equals, false)
+
+	values, err := decodeVLQ("gBD")
+	c.Assert(err, IsNil)
+	c.Assert(values, DeepEquals, []int{16, -1})
+
+	_, err = ParseSourceMap([]byte(`{"version": 2, "mappings": ""}`))
+	c.Assert(err, NotNil)
+	_, err = ParseSourceMap([]byte(`{"version": 3, "sources": ["a.js"], "mappings": "AA!A"}`))
+	c.Assert(err, NotNil)
+}
+
+func (s *DuktapeSuite) TestRegisterSourceMap(c *C) {
+	c.Assert(s.ctx.RegisterSourceMap("bundle.min.js", []byte(bundleMap)), IsNil)
+
+	s.ctx.PushString("bundle.min.js")
+	c.Assert(s.ctx.PcompileStringFilename(0, bundle), IsNil)
+	err := s.ctx.castStringToError(s.ctx.Pcall(0)).(*Error)
+	c.Assert(err.FileName, Equals, "src/a.js")
+	c.Assert(err.LineNumber, Equals, 10)
+
+	var locations []string
+	for _, frame := range err.Frames {
+		if !frame.Native {
+			locations = append(locations, frame.String())
+		}
+	}
+	c.Assert(locations, DeepEquals, []string{
+		"a\n\tsrc/a.js:10",
+		"b\n\tsrc/b.js:3",
+		"global\n\tsrc/b.js:5",
+	})
+	s.ctx.Pop()
+
+	c.Assert(s.ctx.RegisterSourceMap("bundle.min.js", nil), IsNil)
+	s.ctx.PushString("bundle.min.js")
+	c.Assert(s.ctx.PcompileStringFilename(0, bundle), IsNil)
+	err = s.ctx.castStringToError(s.ctx.Pcall(0)).(*Error)
+	c.Assert(err.FileName, Equals, "bundle.min.js")
+	c.Assert(err.LineNumber, Equals, 1)
+
+	c.Assert(s.ctx.RegisterSourceMap("other.js", []byte(`{`)), NotNil)
+}
+
+// oneLine is bundle minified to a single line, mapped by oneLineMap:
+// a from column 0 and b from column 34, with their bodies on line 2 and
+// the call of b on line 4 of src/b.js.
+const oneLine = `function a(){throw new Error("x")}function b(){a()}b();`
+
+const oneLineMap = `{
+	"version": 3,
+	"sources": ["src/a.js", "src/b.js"],
+	"mappings": "AAAA,aACE,qBCDF,aACE,IAEF;ADHA,C"
+}`
+
+func (s *DuktapeSuite) TestSourceMapColumns(c *C) {
+	m, err := ParseSourceMap([]byte(oneLineMap))
+	c.Assert(err, IsNil)
+	for _, t := range []struct {
+		column int
+		pos    SourcePosition
+	}{
+		{0, SourcePosition{Source: "src/a.js", Line: 1}},
+		{20, SourcePosition{Source: "src/a.js", Line: 2, Column: 2}},
+		{34, SourcePosition{Source: "src/b.js", Line: 1}},
+		{100, SourcePosition{Source: "src/b.js", Line: 4}},
+	} {
+		pos, ok := m.Lookup(1, t.column)
+		c.Assert(ok, Equals, true)
+		c.Assert(pos, Equals, t.pos)
+	}
+	// without a column the line is ambiguous, column 1 of line 2 is
+	// unmapped
+	_, ok := m.Lookup(1, -1)
+	c.Assert(ok, Equals, false)
+	pos, ok := m.Lookup(2, 0)
+	c.Assert(ok, Equals, true)
+	c.Assert(pos, Equals, SourcePosition{Source: "src/a.js", Line: 1})
+	_, ok = m.Lookup(2, 5)
+	c.Assert(ok, Equals, false)
+
+	c.Assert(s.ctx.RegisterSourceMap("one.min.js", []byte(oneLineMap)), IsNil)
+	s.ctx.PushString("one.min.js")
+	c.Assert(s.ctx.PcompileStringFilename(0, oneLine), IsNil)
+	e := s.ctx.castStringToError(s.ctx.Pcall(0)).(*Error)
+	s.ctx.Pop()
+	c.Assert(e.FileName, Equals, "src/a.js")
+	c.Assert(e.LineNumber, Equals, 2)
+
+	var locations []string
+	for _, frame := range e.Frames {
+		if !frame.Native {
+			locations = append(locations, frame.String())
+		}
+	}
+	c.Assert(locations, DeepEquals, []string{
+		"a\n\tsrc/a.js:2",
+		"b\n\tsrc/b.js:2",
+		"global\n\tsrc/b.js:4",
+	})
+}
+
+func (s *DuktapeSuite) TestCallstackSourceMap(c *C) {
+	c.Assert(s.ctx.RegisterSourceMap("c.min.js", []byte(`{
+		"version": 3,
+		"sources": ["c.js"],
+		"mappings": "AAAA,aACE,QAEF"
+	}`)), IsNil)
+
+	var frames []Frame
+	s.ctx.PushGlobalGoFunction("where", func(ctx *Context) int {
+		frames = ctx.Callstack()
+		return 0
+	})
+	s.ctx.PushString("c.min.js")
+	c.Assert(s.ctx.PcompileStringFilename(0, `function a(){where()}a();`), IsNil)
+	c.Assert(s.ctx.Pcall(0), Equals, 0)
+	s.ctx.Pop()
+
+	c.Assert(frames, HasLen, 3)
+	c.Assert(frames[0].Go, Equals, true)
+	c.Assert(frames[1].File, Equals, "c.js")
+	c.Assert(frames[1].Line, Equals, 2)
+	c.Assert(frames[1].Column, Equals, 2)
+	c.Assert(frames[2].File, Equals, "c.js")
+	c.Assert(frames[2].Line, Equals, 4)
+	c.Assert(frames[2].Column, Equals, 0)
+}
@@ -0,0 +1,200 @@
+package duktape
+
+/*
+#include <stdlib.h>
+#include "duktape.h"
+
+extern void goDecodeChar(void *udata, duk_codepoint_t codepoint);
+extern duk_codepoint_t goMapChar(void *udata, duk_codepoint_t codepoint);
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// DecodeString calls fn for every codepoint of the string at index, reading
+// the string in place instead of copying it into Go. Non-BMP characters
+// are reported as their two surrogate codepoints, the way Duktape stores
+// them. The value at index must be a string.
+//
+// See: http://duktape.org/api.html#duk_decode_string
+func (d *Context) DecodeString(index int, fn func(codepoint rune)) {
+	call := &stringCall{decode: fn}
+	ptr := stringCallbacks.add(call)
+	defer stringCallbacks.delete(ptr)
+
+	C.duk_decode_string(d.duk_context, C.duk_idx_t(index), (*[0]byte)(C.goDecodeChar), ptr)
+	call.repanic()
+}
+
+// MapString replaces the string at index with the string made of fn applied
+// to each of its codepoints. Like with DecodeString, non-BMP characters are
+// passed as their surrogate codepoints. The value at index must be a string.
+//
+// See: http://duktape.org/api.html#duk_map_string
+func (d *Context) MapString(index int, fn func(codepoint rune) rune) {
+	call := &stringCall{mapChar: fn}
+	ptr := stringCallbacks.add(call)
+	defer stringCallbacks.delete(ptr)
+
+	C.duk_map_string(d.duk_context, C.duk_idx_t(index), (*[0]byte)(C.goMapChar), ptr)
+	call.repanic()
+}
+
+// See: http://duktape.org/api.html#duk_char_code_at
+func (d *Context) CharCodeAt(index int, charOffset uint) rune {
+	return rune(C.duk_char_code_at(d.duk_context, C.duk_idx_t(index), C.duk_size_t(charOffset)))
+}
+
+// PushSprintf formats according to a fmt format specifier, pushes the
+// result to the stack and returns it. The formatting is done by the fmt
+// package, so the Go verbs apply instead of the C printf ones.
+//
+// See: http://duktape.org/api.html#duk_push_sprintf
+func (d *Context) PushSprintf(format string, a ...interface{}) string {
+	s := fmt.Sprintf(format, a...)
+	d.PushString(s)
+	return s
+}
+
+type callbackIndex struct {
+	callbacks map[unsafe.Pointer]interface{}
+	sync.RWMutex
+}
+
+func (i *callbackIndex) add(fn interface{}) unsafe.Pointer {
+	ptr := C.malloc(1)
+
+	i.Lock()
+	i.callbacks[ptr] = fn
+	i.Unlock()
+
+	return ptr
+}
+
+func (i *callbackIndex) get(ptr unsafe.Pointer) interface{} {
+	i.RLock()
+	fn := i.callbacks[ptr]
+	i.RUnlock()
+
+	return fn
+}
+
+func (i *callbackIndex) delete(ptr unsafe.Pointer) {
+	i.Lock()
+	delete(i.callbacks, ptr)
+	i.Unlock()
+
+	C.free(ptr)
+}
+
+var stringCallbacks = &callbackIndex{
+	callbacks: make(map[unsafe.Pointer]interface{}),
+}
+
+// stringCall is a DecodeString or MapString call. A panic of its function
+// must not unwind through the C frames of Duktape: it is recovered, the
+// function is not called for the remaining codepoints and the panic is
+// raised again once Duktape returns.
+type stringCall struct {
+	decode   func(rune)
+	mapChar  func(rune) rune
+	panicked bool
+	value    interface{}
+}
+
+func (c *stringCall) recover() {
+	if r := recover(); r != nil {
+		c.panicked, c.value = true, r
+	}
+}
+
+func (c *stringCall) repanic() {
+	if c.panicked {
+		panic(c.value)
+	}
+}
+
+//export goDecodeChar
+func goDecodeChar(udata unsafe.Pointer, codepoint C.duk_codepoint_t) {
+	call := stringCallbacks.get(udata).(*stringCall)
+	if call.panicked {
+		return
+	}
+	defer call.recover()
+
+	call.decode(rune(codepoint))
+}
+
+//export goMapChar
+func goMapChar(udata unsafe.Pointer, codepoint C.duk_codepoint_t) (result C.duk_codepoint_t) {
+	call := stringCallbacks.get(udata).(*stringCall)
+	if call.panicked {
+		return codepoint
+	}
+	result = codepoint
+	defer call.recover()
+
+	return C.duk_codepoint_t(call.mapChar(rune(codepoint)))
+}
+
+// emptyString is handed to Duktape for empty Go strings, because the
+// compile and eval calls read the source from the stack when it is NULL.
+var emptyString = C.CString("")
+
+// lstring returns a pointer to the bytes of s and the length to pass to
+// the *_lstring functions of Duktape, which copy what they need during
+// the call. No C copy is made, so it is safe for strings with NUL bytes
+// and does not allocate. length is clamped to the length of s.
+func lstring(s string, length int) (*C.char, C.duk_size_t) {
+	if length > len(s) {
+		length = len(s)
+	}
+	if length <= 0 {
+		return emptyString, 0
+	}
+	return (*C.char)(unsafe.Pointer(unsafe.StringData(s))), C.duk_size_t(length)
+}
+
+// pushString pushes s, converted to CESU-8 if the heap normalizes UTF-8.
+// Symbol keys are pushed as they are.
+func (d *Context) pushString(s string) {
+	s = d.heapString(s)
+	__s__, __len__ := lstring(s, len(s))
+	C.duk_push_lstring(d.duk_context, __s__, __len__)
+}
+
+// heapString returns s, a property key or a source passed to Duktape,
+// converted to CESU-8 if the heap normalizes UTF-8. Symbol keys are kept
+// as they are.
+func (d *Context) heapString(s string) string {
+	if d.normalizeUTF8 && !isSymbolKey(s) {
+		return UTF8ToCESU8(s)
+	}
+	return s
+}
+
+// heapLstring is heapString for the first length bytes of s.
+func (d *Context) heapLstring(s string, length int) string {
+	if length < 0 {
+		length = 0
+	}
+	if length < len(s) {
+		s = s[:length]
+	}
+	return d.heapString(s)
+}
+
+// goString copies the length bytes of the Duktape string s into a Go
+// string, converted to valid UTF-8 if the heap normalizes UTF-8. Symbols,
+// whose internal keys start with a byte never valid in UTF-8, are copied
+// as they are.
+func (d *Context) goString(s *C.char, length C.duk_size_t) string {
+	str := C.GoStringN(s, C.int(length))
+	if d.normalizeUTF8 && !isSymbolKey(str) {
+		str = CESU8ToUTF8(str)
+	}
+	return str
+}
@@ -0,0 +1,56 @@
+package duktape
+
+import (
+	"unicode"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *DuktapeSuite) TestDecodeString(c *C) {
+	s.ctx.PushString("añ€")
+	var codepoints []rune
+	s.ctx.DecodeString(-1, func(r rune) {
+		codepoints = append(codepoints, r)
+	})
+	c.Assert(codepoints, DeepEquals, []rune{'a', 'ñ', '€'})
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestDecodeStringSurrogates(c *C) {
+	err := s.ctx.PevalString(`'x😀'`)
+	c.Assert(err, IsNil)
+	var codepoints []rune
+	s.ctx.DecodeString(-1, func(r rune) {
+		codepoints = append(codepoints, r)
+	})
+	c.Assert(codepoints, DeepEquals, []rune{'x', 0xD83D, 0xDE00})
+}
+
+func (s *DuktapeSuite) TestMapString(c *C) {
+	s.ctx.PushString("Hello, wörld")
+	s.ctx.MapString(-1, unicode.ToUpper)
+	c.Assert(s.ctx.GetString(-1), Equals, "HELLO, WÖRLD")
+
+	s.ctx.MapString(-1, func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+		return r
+	})
+	c.Assert(s.ctx.GetString(-1), Equals, "HELLO  WÖRLD")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestCharCodeAt(c *C) {
+	s.ctx.PushString("añ€")
+	c.Assert(s.ctx.CharCodeAt(-1, 0), Equals, 'a')
+	c.Assert(s.ctx.CharCodeAt(-1, 1), Equals, 'ñ')
+	c.Assert(s.ctx.CharCodeAt(-1, 2), Equals, '€')
+	c.Assert(s.ctx.CharCodeAt(-1, 3), Equals, rune(0))
+}
+
+func (s *DuktapeSuite) TestPushSprintf(c *C) {
+	str := s.ctx.PushSprintf("%s=%d (%.1f)", "answer", 42, 0.5)
+	c.Assert(str, Equals, "answer=42 (0.5)")
+	c.Assert(s.ctx.GetString(-1), Equals, str)
+}
@@ -0,0 +1,223 @@
+package duktape
+
+/*
+#include "duktape.h"
+*/
+import "C"
+import "strings"
+
+// Duktape represents symbols as strings starting with an invalid UTF-8
+// byte, see: https://github.com/svaarala/duktape/blob/master/doc/symbols.rst
+const (
+	symbolGlobalPrefix = "\x80"
+	symbolLocalPrefix  = "\x81"
+	symbolHiddenPrefix = "\xff"
+	symbolHiddenAlt    = "\x82"
+	symbolSuffix       = "\xff"
+)
+
+// isSymbolKey reports whether the internal string key is a symbol.
+func isSymbolKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	switch key[0] {
+	case symbolGlobalPrefix[0], symbolLocalPrefix[0], symbolHiddenAlt[0], symbolHiddenPrefix[0]:
+		return true
+	}
+	return false
+}
+
+// convertSymbolKey applies conv, a conversion between UTF-8 and CESU-8, to
+// the description in the symbol key, keeping the marker bytes around it.
+func convertSymbolKey(key string, conv func(string) string) string {
+	if key == "" {
+		return key
+	}
+	desc, tail := key[1:], ""
+	if i := strings.IndexByte(desc, symbolSuffix[0]); i >= 0 {
+		desc, tail = desc[:i], desc[i:]
+	}
+	return key[:1] + conv(desc) + tail
+}
+
+// SymbolKind tells the different kinds of symbols apart.
+type SymbolKind uint
+
+const (
+	// SymbolLocal is a unique symbol created with Symbol().
+	SymbolLocal SymbolKind = iota
+	// SymbolGlobal is a symbol from the registry, Symbol.for().
+	SymbolGlobal
+	// SymbolWellKnown is a well-known symbol such as Symbol.iterator.
+	SymbolWellKnown
+	// SymbolHidden is a Duktape hidden symbol, which scripts can not
+	// access or enumerate.
+	SymbolHidden
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolLocal:
+		return "Local"
+	case SymbolGlobal:
+		return "Global"
+	case SymbolWellKnown:
+		return "WellKnown"
+	case SymbolHidden:
+		return "Hidden"
+	default:
+		return "Unknown"
+	}
+}
+
+// Symbol is a Go handle of a JavaScript symbol. It holds the internal key
+// Duktape uses for the symbol, so a Symbol obtained from one value refers
+// to the very same symbol whenever it is pushed back into the same heap.
+// On heaps with Flags.NormalizeUTF8 the description in the key is UTF-8,
+// and converted to and from CESU-8 at the boundary.
+type Symbol struct {
+	key string
+}
+
+// GlobalSymbol returns the symbol registered under key, the same one
+// Symbol.for(key) returns.
+func GlobalSymbol(key string) Symbol {
+	return Symbol{symbolGlobalPrefix + key}
+}
+
+// HiddenSymbol returns the hidden symbol called name. Properties keyed by
+// hidden symbols are only reachable from Go (or C), which makes them
+// suitable for private host data.
+func HiddenSymbol(name string) Symbol {
+	return Symbol{symbolHiddenPrefix + name}
+}
+
+// WellKnownSymbol returns the well-known symbol Symbol.<name>, e.g.
+// WellKnownSymbol("iterator") for Symbol.iterator.
+func WellKnownSymbol(name string) Symbol {
+	return Symbol{symbolLocalPrefix + "Symbol." + name + symbolSuffix}
+}
+
+// Kind reports how the symbol was created.
+func (s Symbol) Kind() SymbolKind {
+	switch {
+	case strings.HasPrefix(s.key, symbolGlobalPrefix):
+		return SymbolGlobal
+	case strings.HasPrefix(s.key, symbolHiddenPrefix), strings.HasPrefix(s.key, symbolHiddenAlt):
+		return SymbolHidden
+	case strings.HasSuffix(s.key, symbolSuffix):
+		return SymbolWellKnown
+	default:
+		return SymbolLocal
+	}
+}
+
+// Description returns the description of the symbol: the registry key for
+// global symbols and the name for hidden ones.
+func (s Symbol) Description() string {
+	if s.key == "" {
+		return ""
+	}
+	desc := s.key[1:]
+	if s.Kind() == SymbolHidden {
+		return desc
+	}
+	if i := strings.Index(desc, symbolSuffix); i >= 0 {
+		desc = desc[:i]
+	}
+	return desc
+}
+
+func (s Symbol) String() string {
+	return "Symbol(" + s.Description() + ")"
+}
+
+// IsSymbol checks whether the value at index is a symbol. Duktape stores
+// symbols as strings, so IsString is true for them too, but GetType reports
+// TypeSymbol and GetString returns "".
+//
+// See: http://duktape.org/api.html#duk_is_symbol
+func (d *Context) IsSymbol(index int) bool {
+	return int(C.duk_is_symbol(d.duk_context, C.duk_idx_t(index))) == 1
+}
+
+// GetSymbol returns the symbol at index. The second result is false if the
+// value is not a symbol.
+func (d *Context) GetSymbol(index int) (Symbol, bool) {
+	if !d.IsSymbol(index) {
+		return Symbol{}, false
+	}
+	key := d.rawString(index)
+	if d.normalizeUTF8 {
+		key = convertSymbolKey(key, CESU8ToUTF8)
+	}
+	return Symbol{key}, true
+}
+
+// PushSymbol creates a new unique symbol with the given description, like
+// Symbol(description) does, pushes it to the stack and returns it.
+func (d *Context) PushSymbol(description string) Symbol {
+	d.GetGlobalString("Symbol")
+	d.PushString(description)
+	d.Call(1)
+	s, _ := d.GetSymbol(-1)
+	return s
+}
+
+// PushGlobalSymbol pushes the registered symbol for key, like
+// Symbol.for(key) does, and returns it.
+func (d *Context) PushGlobalSymbol(key string) Symbol {
+	s := GlobalSymbol(key)
+	d.PushSymbolValue(s)
+	return s
+}
+
+// PushHiddenSymbol pushes the hidden symbol called name and returns it.
+func (d *Context) PushHiddenSymbol(name string) Symbol {
+	s := HiddenSymbol(name)
+	d.PushSymbolValue(s)
+	return s
+}
+
+// PushSymbolValue pushes the symbol s to the stack.
+func (d *Context) PushSymbolValue(s Symbol) {
+	key := s.key
+	if d.normalizeUTF8 {
+		key = convertSymbolKey(key, UTF8ToCESU8)
+	}
+	__key__, __len__ := lstring(key, len(key))
+	C.duk_push_lstring(d.duk_context, __key__, __len__)
+}
+
+// GetPropSymbol gets the property keyed by s of the object at objIndex and
+// pushes it to the stack, like GetPropString does for string keys.
+func (d *Context) GetPropSymbol(objIndex int, s Symbol) bool {
+	objIndex = d.NormalizeIndex(objIndex)
+	d.PushSymbolValue(s)
+	return d.GetProp(objIndex)
+}
+
+// PutPropSymbol writes the value on top of the stack to the property keyed
+// by s of the object at objIndex and pops the value.
+func (d *Context) PutPropSymbol(objIndex int, s Symbol) bool {
+	objIndex = d.NormalizeIndex(objIndex)
+	d.PushSymbolValue(s)
+	d.Swap(-2, -1)
+	return d.PutProp(objIndex)
+}
+
+// HasPropSymbol checks whether the object at objIndex has a property keyed
+// by s.
+func (d *Context) HasPropSymbol(objIndex int, s Symbol) bool {
+	objIndex = d.NormalizeIndex(objIndex)
+	d.PushSymbolValue(s)
+	return d.HasProp(objIndex)
+}
+
+// DelPropSymbol deletes the property keyed by s of the object at objIndex.
+func (d *Context) DelPropSymbol(objIndex int, s Symbol) bool {
+	objIndex = d.NormalizeIndex(objIndex)
+	d.PushSymbolValue(s)
+	return d.DelProp(objIndex)
+}
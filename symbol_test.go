@@ -0,0 +1,112 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestPushSymbol(c *C) {
+	sym := s.ctx.PushSymbol("foo")
+	c.Assert(s.ctx.IsSymbol(-1), Equals, true)
+	c.Assert(s.ctx.GetType(-1), Equals, TypeSymbol)
+	c.Assert(sym.Kind(), Equals, SymbolLocal)
+	c.Assert(sym.Description(), Equals, "foo")
+	c.Assert(sym.String(), Equals, "Symbol(foo)")
+
+	other := s.ctx.PushSymbol("foo")
+	c.Assert(other, Not(Equals), sym)
+	c.Assert(s.ctx.StrictEquals(-1, -2), Equals, false)
+}
+
+func (s *DuktapeSuite) TestPushGlobalSymbol(c *C) {
+	sym := s.ctx.PushGlobalSymbol("app.id")
+	c.Assert(sym.Kind(), Equals, SymbolGlobal)
+	c.Assert(sym.Description(), Equals, "app.id")
+
+	s.ctx.PevalString(`Symbol.for("app.id")`)
+	c.Assert(s.ctx.StrictEquals(-1, -2), Equals, true)
+}
+
+func (s *DuktapeSuite) TestWellKnownSymbol(c *C) {
+	s.ctx.PevalString(`Symbol.toStringTag`)
+	sym, ok := s.ctx.GetSymbol(-1)
+	c.Assert(ok, Equals, true)
+	c.Assert(sym, Equals, WellKnownSymbol("toStringTag"))
+	c.Assert(sym.Kind(), Equals, SymbolWellKnown)
+	c.Assert(sym.Description(), Equals, "Symbol.toStringTag")
+
+	s.ctx.PushObject()
+	s.ctx.PushString("Custom")
+	s.ctx.PutPropSymbol(-2, sym)
+	s.ctx.PutGlobalString("obj")
+	s.ctx.PevalString(`Object.prototype.toString.call(obj)`)
+	c.Assert(s.ctx.GetString(-1), Equals, "[object Custom]")
+}
+
+func (s *DuktapeSuite) TestHiddenSymbol(c *C) {
+	s.ctx.PushObject()
+	s.ctx.PushString("secret")
+	c.Assert(s.ctx.PutPropSymbol(-2, HiddenSymbol("data")), Equals, true)
+	c.Assert(s.ctx.HasPropSymbol(-1, HiddenSymbol("data")), Equals, true)
+	s.ctx.DupTop()
+	s.ctx.PutGlobalString("obj")
+
+	err := s.ctx.PevalString(`Object.getOwnPropertySymbols(obj).length + Object.keys(obj).length`)
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetInt(-1), Equals, 0)
+	s.ctx.Pop()
+
+	c.Assert(s.ctx.GetPropSymbol(-1, HiddenSymbol("data")), Equals, true)
+	c.Assert(s.ctx.GetString(-1), Equals, "secret")
+	s.ctx.Pop()
+
+	c.Assert(s.ctx.DelPropSymbol(-1, HiddenSymbol("data")), Equals, true)
+	c.Assert(s.ctx.HasPropSymbol(-1, HiddenSymbol("data")), Equals, false)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+}
+
+func (s *DuktapeSuite) TestGetSymbol_NotSymbol(c *C) {
+	s.ctx.PushString("foo")
+	_, ok := s.ctx.GetSymbol(-1)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *DuktapeSuite) TestSymbolDecoding(c *C) {
+	s.ctx.PushSymbol("foo")
+	c.Assert(s.ctx.GetType(-1), Equals, TypeSymbol)
+	c.Assert(s.ctx.GetType(-1).String(), Equals, "Symbol")
+	c.Assert(s.ctx.InspectValue(-1).Type, Equals, TypeSymbol)
+	c.Assert(s.ctx.GetString(-1), Equals, "")
+	c.Assert(s.ctx.GetLstring(-1), Equals, "")
+	c.Assert(s.ctx.SafeToString(-1), Equals, "Symbol(foo)")
+	c.Assert(s.ctx.GetType(-1), Equals, TypeString)
+	c.Assert(s.ctx.GetString(-1), Equals, "Symbol(foo)")
+}
+
+func (s *DuktapeSuite) TestSymbolNormalizeUTF8(c *C) {
+	ctx := NewWithFlags(&Flags{NormalizeUTF8: true})
+	defer ctx.DestroyHeap()
+
+	ctx.PevalString(`Symbol("😀")`)
+	sym, ok := ctx.GetSymbol(-1)
+	c.Assert(ok, Equals, true)
+	c.Assert(sym.Description(), Equals, "😀")
+	c.Assert(ctx.SafeToString(-1), Equals, "Symbol(😀)")
+	ctx.Pop()
+
+	err := ctx.PevalString(`var o = {}; o[Symbol.for("app.😀")] = 1; o`)
+	c.Assert(err, IsNil)
+	c.Assert(ctx.GetPropSymbol(-1, GlobalSymbol("app.😀")), Equals, true)
+	c.Assert(ctx.GetInt(-1), Equals, 1)
+	ctx.Pop()
+
+	var keys []string
+	ctx.RangeObject(-1, EnumIncludeSymbols, func(key string, c *Context) bool {
+		keys = append(keys, key)
+		return true
+	})
+	c.Assert(keys, HasLen, 1)
+	ctx.PushString(keys[0])
+	sym, ok = ctx.GetSymbol(-1)
+	c.Assert(ok, Equals, true)
+	c.Assert(sym, Equals, GlobalSymbol("app.😀"))
+	c.Assert(ctx.GetProp(-2), Equals, true)
+	c.Assert(ctx.GetInt(-1), Equals, 1)
+}
@@ -52,7 +52,7 @@ func setTimeout(c *Context) int {
 		// check if timer still exists
 		c.putTimer(id)
 		if c.GetType(-1).IsObject() {
-			c.Pcall(0 /* nargs */)
+			c.timerPcall()
 		}
 		c.dropTimer(id)
 	}(id)
@@ -91,7 +91,7 @@ func setInterval(c *Context) int {
 			// check if timer still exists
 			c.putTimer(id)
 			if c.GetType(-1).IsObject() {
-				c.Pcall(0 /* nargs */)
+				c.timerPcall()
 				c.Pop()
 			} else {
 				c.dropTimer(id)
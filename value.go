@@ -0,0 +1,172 @@
+package duktape
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// PushGoValue pushes the JavaScript equivalent of the Go value v:
+//
+//	nil, nil pointers        null
+//	bool                     boolean
+//	integers and floats      number
+//	string                   string
+//	[]byte                   plain buffer holding a copy of the bytes
+//	Symbol                   symbol
+//	func(*Context) int       Go function, see PushGoFunction
+//	slices and arrays        array
+//	maps with string keys    object
+//
+// Pointers are followed, anything else goes through encoding/json. Map
+// keys are pushed in sorted order. If the value can not be converted, e.g.
+// because it refers to itself, undefined is pushed and an error returned.
+func (d *Context) PushGoValue(v interface{}) error {
+	return d.pushGoValue(v, nil)
+}
+
+// visit identifies a pointer, slice or map being pushed. Slices sharing an
+// array are told apart by their length, like encoding/json does.
+type visit struct {
+	ptr uintptr
+	len int
+}
+
+func (d *Context) pushGoValue(v interface{}, seen map[visit]bool) error {
+	switch v := v.(type) {
+	case nil:
+		d.PushNull()
+	case bool:
+		d.PushBoolean(v)
+	case int:
+		d.PushNumber(float64(v))
+	case int32:
+		d.PushNumber(float64(v))
+	case int64:
+		d.PushNumber(float64(v))
+	case uint:
+		d.PushNumber(float64(v))
+	case uint32:
+		d.PushNumber(float64(v))
+	case uint64:
+		d.PushNumber(float64(v))
+	case float32:
+		d.PushNumber(float64(v))
+	case float64:
+		d.PushNumber(v)
+	case string:
+		d.PushString(v)
+	case []byte:
+		copy(d.pushFixedBytes(len(v)), v)
+	case Symbol:
+		d.PushSymbolValue(v)
+	case func(*Context) int:
+		d.PushGoFunction(v)
+	default:
+		return d.pushReflectValue(reflect.ValueOf(v), seen)
+	}
+	return nil
+}
+
+// pushReflectValue pushes v, seen holds the pointers, slices and maps the
+// callers are pushing to detect cycles.
+func (d *Context) pushReflectValue(v reflect.Value, seen map[visit]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		key := visit{ptr: v.Pointer()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if seen[key] {
+			d.PushUndefined()
+			return fmt.Errorf("cannot push cyclic value of type %s", v.Type())
+		}
+		if seen == nil {
+			seen = make(map[visit]bool)
+		}
+		seen[key] = true
+		defer delete(seen, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			d.PushNull()
+			return nil
+		}
+		return d.pushGoValue(v.Elem().Interface(), seen)
+	case reflect.Bool:
+		d.PushBoolean(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.PushNumber(float64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		d.PushNumber(float64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		d.PushNumber(v.Float())
+	case reflect.String:
+		d.PushString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			d.PushNull()
+			return nil
+		}
+		arr := d.PushArray()
+		for i := 0; i < v.Len(); i++ {
+			if err := d.pushGoValue(v.Index(i).Interface(), seen); err != nil {
+				d.SetTop(arr)
+				d.PushUndefined()
+				return err
+			}
+			d.PutPropIndex(arr, uint(i))
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			d.PushUndefined()
+			return fmt.Errorf("cannot push map with %s keys", v.Type().Key())
+		}
+		if v.IsNil() {
+			d.PushNull()
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		obj := d.PushObject()
+		for _, key := range keys {
+			if err := d.pushGoValue(v.MapIndex(key).Interface(), seen); err != nil {
+				d.SetTop(obj)
+				d.PushUndefined()
+				return err
+			}
+			d.PutPropString(obj, key.String())
+		}
+	default:
+		if !v.IsValid() {
+			d.PushNull()
+			return nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			d.PushUndefined()
+			return err
+		}
+		d.PushString(string(data))
+		d.JsonDecode(-1)
+	}
+
+	return nil
+}
+
+// pushFixedBytes pushes a fixed buffer of the given size and returns its
+// memory as a byte slice.
+func (d *Context) pushFixedBytes(size int) []byte {
+	rawPtr := d.PushFixedBuffer(size)
+	if size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(rawPtr), size)
+}
@@ -0,0 +1,93 @@
+package duktape
+
+import . "gopkg.in/check.v1"
+
+func (s *DuktapeSuite) TestPushGoValue(c *C) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	var nilPtr *point
+
+	err := s.ctx.PushGoValue(map[string]interface{}{
+		"nil":    nil,
+		"ptr":    nilPtr,
+		"bool":   true,
+		"int":    -3,
+		"uint8":  uint8(7),
+		"float":  1.5,
+		"string": "duk",
+		"list":   []string{"a", "b"},
+		"array":  [2]int{1, 2},
+		"nested": map[string]int{"n": 1},
+		"struct": &point{1, 2},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+
+	str, err := s.ctx.EncodeJSON(-1, &EncodeOptions{Keys: []string{
+		"nil", "ptr", "bool", "int", "uint8", "float", "string", "list", "array", "nested", "n", "struct", "x", "y",
+	}})
+	c.Assert(err, IsNil)
+	c.Assert(str, Equals, `{"nil":null,"ptr":null,"bool":true,"int":-3,"uint8":7,"float":1.5,"string":"duk",`+
+		`"list":["a","b"],"array":[1,2],"nested":{"n":1},"struct":{"x":1,"y":2}}`)
+}
+
+func (s *DuktapeSuite) TestPushGoValue_Special(c *C) {
+	s.ctx.PushGoValue([]byte{1, 2, 3})
+	c.Assert(s.ctx.IsBuffer(-1), Equals, true)
+	_, size := s.ctx.GetBuffer(-1)
+	c.Assert(size, Equals, uint(3))
+
+	s.ctx.PushGoValue(GlobalSymbol("key"))
+	c.Assert(s.ctx.IsSymbol(-1), Equals, true)
+
+	s.ctx.PushGoValue(func(ctx *Context) int {
+		ctx.PushInt(42)
+		return 1
+	})
+	s.ctx.Call(0)
+	c.Assert(s.ctx.GetInt(-1), Equals, 42)
+}
+
+func (s *DuktapeSuite) TestPushGoValue_Error(c *C) {
+	err := s.ctx.PushGoValue([]interface{}{1, map[int]string{1: "a"}})
+	c.Assert(err, ErrorMatches, "cannot push map with int keys")
+	c.Assert(s.ctx.GetTop(), Equals, 1)
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+
+	err = s.ctx.PushGoValue(make(chan int))
+	c.Assert(err, NotNil)
+	c.Assert(s.ctx.GetTop(), Equals, 2)
+}
+
+func (s *DuktapeSuite) TestPushGoValue_MapOrder(c *C) {
+	err := s.ctx.PushGoValue(map[string]int{"c": 3, "a": 1, "d": 4, "b": 2})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `{"a":1,"b":2,"c":3,"d":4}`)
+}
+
+func (s *DuktapeSuite) TestPushGoValue_Cycle(c *C) {
+	m := map[string]interface{}{"a": 1}
+	m["self"] = []interface{}{m}
+	err := s.ctx.PushGoValue(m)
+	c.Assert(err, ErrorMatches, "cannot push cyclic value of type map.*")
+	c.Assert(s.ctx.IsUndefined(-1), Equals, true)
+
+	list := []interface{}{nil}
+	list[0] = list
+	err = s.ctx.PushGoValue(list)
+	c.Assert(err, ErrorMatches, `cannot push cyclic value of type \[\]interface {}`)
+
+	p := new(interface{})
+	*p = p
+	err = s.ctx.PushGoValue(p)
+	c.Assert(err, ErrorMatches, `cannot push cyclic value of type \*interface {}`)
+	c.Assert(s.ctx.GetTop(), Equals, 3)
+	s.ctx.SetTop(0)
+
+	shared := []int{1}
+	err = s.ctx.PushGoValue(map[string]interface{}{"a": shared, "b": shared, "c": shared[:0]})
+	c.Assert(err, IsNil)
+	c.Assert(s.ctx.JsonEncode(-1), Equals, `{"a":[1],"b":[1],"c":[]}`)
+}